@@ -10,7 +10,17 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/yourorg/prober/internal/probe"
+	"github.com/yourorg/prober/pkg/probe"
+
+	// Probe kinds register themselves with the probe package.
+	_ "github.com/yourorg/prober/pkg/probe/dns"
+	_ "github.com/yourorg/prober/pkg/probe/http"
+	_ "github.com/yourorg/prober/pkg/probe/kafka"
+	_ "github.com/yourorg/prober/pkg/probe/mysql"
+	_ "github.com/yourorg/prober/pkg/probe/redis"
+	_ "github.com/yourorg/prober/pkg/probe/s3"
+	_ "github.com/yourorg/prober/pkg/probe/tcp"
+	_ "github.com/yourorg/prober/pkg/probe/udp"
 )
 
 func main() {
@@ -22,8 +32,12 @@ func main() {
 		log.Println("Usage: prober <config.yaml> (defaulting to config.yaml)")
 	}
 
-	// Initialize Prometheus metrics (if used)
-	probe.InitMetrics()
+	// Serve Prometheus metrics; the listen address is only read at startup
+	var listenAddr string
+	if cfg, err := probe.LoadConfig(configPath); err == nil {
+		listenAddr = cfg.ListenAddr
+	}
+	probe.InitMetrics(listenAddr)
 
 	// Create a root context for cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -53,8 +67,13 @@ func main() {
 			log.Printf("Failed to load config: %v (probes continue with last good config)", err)
 			return
 		}
+		if err := manager.LaunchOrUpdateProbes(cfg); err != nil {
+			lastConfigError = err
+			lastConfigErrorTime = time.Now()
+			log.Printf("Failed to apply config: %v (probes continue with last good config)", err)
+			return
+		}
 		lastConfigError = nil
-		manager.LaunchOrUpdateProbes(cfg)
 	}
 
 	// Initial load
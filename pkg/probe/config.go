@@ -0,0 +1,268 @@
+package probe
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type DurationString string
+
+func (d DurationString) ToDuration(defaultDuration time.Duration) time.Duration {
+	if d == "" {
+		return defaultDuration
+	}
+	dur, err := time.ParseDuration(string(d))
+	if err != nil {
+		return defaultDuration
+	}
+	return dur
+}
+
+// ProbeConfig is a single entry of the `probes:` list: a registered kind and
+// the cluster config decoded by that kind.
+type ProbeConfig struct {
+	Kind    string
+	Cluster Cluster
+}
+
+func (pc *ProbeConfig) UnmarshalYAML(node *yaml.Node) error {
+	var head struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := node.Decode(&head); err != nil {
+		return err
+	}
+	if head.Kind == "" {
+		return fmt.Errorf("line %d: probe is missing a kind", node.Line)
+	}
+	k, ok := lookupKind(head.Kind)
+	if !ok {
+		return fmt.Errorf("line %d: unknown probe kind %q (known kinds: %s)", node.Line, head.Kind, strings.Join(Kinds(), ", "))
+	}
+	cluster, err := k.decode(node)
+	if err != nil {
+		return err
+	}
+	pc.Kind = head.Kind
+	pc.Cluster = cluster
+	return nil
+}
+
+// Config describes the probes a ProbeManager runs. It is usually loaded from
+// YAML with LoadConfig, but can also be built in code with Add.
+type Config struct {
+	DefaultDuration DurationString `yaml:"defaultDuration"`
+	// Labels are attached to every series emitted by every cluster; cluster
+	// level labels with the same key take precedence.
+	Labels map[string]string `yaml:"labels"`
+	Probes []ProbeConfig     `yaml:"probes"`
+	// Identity is resolved once, when the config is first applied.
+	Identity IdentityConfig `yaml:"identity"`
+	Matrix   MatrixConfig   `yaml:"matrix"`
+	// ListenAddr is where /metrics and /matrix are served (default
+	// DefaultListenAddr). It is read once at startup.
+	ListenAddr string `yaml:"listenAddr"`
+	// KindDefaults holds the per-kind default durations, set by the
+	// defaultDuration of the top-level kind sections (e.g. `redis:`).
+	KindDefaults map[string]DurationString `yaml:"-"`
+}
+
+// configKeys are the top-level keys that belong to Config itself; any other
+// top-level key is the section of a probe kind.
+var configKeys = map[string]bool{
+	"defaultDuration": true,
+	"labels":          true,
+	"probes":          true,
+	"identity":        true,
+	"matrix":          true,
+	"listenAddr":      true,
+}
+
+// UnmarshalYAML decodes the `probes:` list and, for backward compatibility,
+// the top-level per-kind sections such as
+//
+//	redis:
+//	  defaultDuration: 5s
+//	  clusters: [...]
+//
+// Clusters from those sections are appended to Probes.
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plain Config
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		if configKeys[name] {
+			continue
+		}
+		k, ok := lookupKind(name)
+		if !ok {
+			return fmt.Errorf("line %d: unknown config section %q (known kinds: %s)", node.Content[i].Line, name, strings.Join(Kinds(), ", "))
+		}
+		var section struct {
+			DefaultDuration DurationString `yaml:"defaultDuration"`
+			Clusters        []yaml.Node    `yaml:"clusters"`
+		}
+		if err := node.Content[i+1].Decode(&section); err != nil {
+			return err
+		}
+		if section.DefaultDuration != "" {
+			if c.KindDefaults == nil {
+				c.KindDefaults = make(map[string]DurationString)
+			}
+			c.KindDefaults[name] = section.DefaultDuration
+		}
+		for j := range section.Clusters {
+			cluster, err := k.decode(&section.Clusters[j])
+			if err != nil {
+				return err
+			}
+			c.Probes = append(c.Probes, ProbeConfig{Kind: name, Cluster: cluster})
+		}
+	}
+	return nil
+}
+
+// Add appends a cluster to the config. The kind is derived from the type of
+// cluster, e.g. tcp.TCPCluster, and must have been registered.
+func (c *Config) Add(cluster Cluster) error {
+	name, ok := kindOf(cluster)
+	if !ok {
+		return fmt.Errorf("no probe kind registered for cluster config type %T", cluster)
+	}
+	c.Probes = append(c.Probes, ProbeConfig{Kind: name, Cluster: cluster})
+	return nil
+}
+
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var cfg Config
+	dec := yaml.NewDecoder(f)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Interval returns how often the probes of pc run: the cluster duration, else
+// the kind's default duration, else the global default, else 10s.
+func (c *Config) Interval(pc ProbeConfig) time.Duration {
+	return pc.Cluster.Base().Duration.ToDuration(
+		c.KindDefaults[pc.Kind].ToDuration(
+			c.DefaultDuration.ToDuration(10 * time.Second),
+		),
+	)
+}
+
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ClusterLabels merges the global labels with the labels of a single cluster.
+func (c *Config) ClusterLabels(clusterLabels map[string]string) map[string]string {
+	labels := make(map[string]string, len(c.Labels)+len(clusterLabels))
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	for k, v := range clusterLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// LabelKeys returns the sorted union of custom label keys used by all clusters.
+func (c *Config) LabelKeys() []string {
+	seen := make(map[string]struct{})
+	for k := range c.Labels {
+		seen[k] = struct{}{}
+	}
+	for _, pc := range c.Probes {
+		for k := range pc.Cluster.Base().Labels {
+			seen[k] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks the identity providers and matrix peers, that every probe
+// has a known kind and a unique name within its kind, runs the cluster's own
+// validation, and checks that custom labels are valid Prometheus label names,
+// don't shadow the built-in labels, and that all clusters of a kind share the
+// same label key set.
+func (c *Config) Validate() error {
+	if _, err := c.Identity.providers(); err != nil {
+		return err
+	}
+	for _, peer := range c.Matrix.Peers {
+		if u, err := url.Parse(peer); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid matrix peer URL %q", peer)
+		}
+	}
+	type ref struct {
+		name string
+		keys string
+	}
+	firstOfKind := make(map[string]ref)
+	seen := make(map[probeKey]bool)
+	for _, pc := range c.Probes {
+		if _, ok := lookupKind(pc.Kind); !ok {
+			return fmt.Errorf("unknown probe kind %q", pc.Kind)
+		}
+		name := pc.Cluster.Base().Name
+		key := probeKey{Kind: pc.Kind, Name: name}
+		if seen[key] {
+			return fmt.Errorf("%s cluster %s is defined more than once", pc.Kind, name)
+		}
+		seen[key] = true
+		if v, ok := pc.Cluster.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("%s cluster %s: %w", pc.Kind, name, err)
+			}
+		}
+
+		labels := c.ClusterLabels(pc.Cluster.Base().Labels)
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			if !labelNameRE.MatchString(k) || strings.HasPrefix(k, "__") {
+				return fmt.Errorf("%s cluster %s: invalid label name %q", pc.Kind, name, k)
+			}
+			if isReservedLabel(k) {
+				return fmt.Errorf("%s cluster %s: label %q is reserved", pc.Kind, name, k)
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		joined := strings.Join(keys, ",")
+		first, ok := firstOfKind[pc.Kind]
+		if !ok {
+			firstOfKind[pc.Kind] = ref{name: name, keys: joined}
+			continue
+		}
+		if joined != first.keys {
+			return fmt.Errorf("%s clusters %s and %s use different label keys: [%s] vs [%s]",
+				pc.Kind, first.name, name, first.keys, joined)
+		}
+	}
+	return nil
+}
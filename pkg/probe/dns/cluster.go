@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type DNSCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	// Servers are host:port; the port defaults to 53, or 853 over TLS.
+	Servers   []string             `yaml:"servers"`
+	QueryName string               `yaml:"queryName"`
+	QueryType string               `yaml:"queryType"`
+	Transport string               `yaml:"transport"`
+	TLS       tlsconfig.Config     `yaml:"tls"`
+	Timeout   probe.DurationString `yaml:"timeout"`
+	// Checks on the response; see Checks. MinAnswers defaults to 1, set it
+	// to 0 to accept empty answers.
+	ValidRcodes      []string             `yaml:"validRcodes"`
+	MinAnswers       *int                 `yaml:"minAnswers"`
+	MaxAnswers       int                  `yaml:"maxAnswers"`
+	AnswerMatches    []string             `yaml:"answerMatches"`
+	AnswerNotMatches []string             `yaml:"answerNotMatches"`
+	MinTTL           probe.DurationString `yaml:"minTTL"`
+	MaxTTL           probe.DurationString `yaml:"maxTTL"`
+	RequireAD        bool                 `yaml:"requireAD"`
+}
+
+func init() {
+	probe.Register("dns", newProbers)
+}
+
+func (c DNSCluster) checks() Checks {
+	minAnswers := 1
+	if c.MinAnswers != nil {
+		minAnswers = *c.MinAnswers
+	}
+	return Checks{
+		ValidRcodes:      c.ValidRcodes,
+		MinAnswers:       minAnswers,
+		MaxAnswers:       c.MaxAnswers,
+		AnswerMatches:    c.AnswerMatches,
+		AnswerNotMatches: c.AnswerNotMatches,
+		MinTTL:           c.MinTTL.ToDuration(0),
+		MaxTTL:           c.MaxTTL.ToDuration(0),
+		RequireAD:        c.RequireAD,
+	}
+}
+
+func (c DNSCluster) Validate() error {
+	if len(c.Servers) == 0 {
+		return fmt.Errorf("at least one server is required")
+	}
+	if c.QueryName == "" {
+		return fmt.Errorf("queryName is required")
+	}
+	if c.QueryType != "" {
+		if _, err := parseType(c.QueryType); err != nil {
+			return fmt.Errorf("queryType: %w", err)
+		}
+	}
+	switch c.Transport {
+	case "", TransportUDP, TransportTCP, TransportTLS:
+	default:
+		return fmt.Errorf("invalid transport %q, want udp, tcp or tls", c.Transport)
+	}
+	for name, d := range map[string]probe.DurationString{"minTTL": c.MinTTL, "maxTTL": c.MaxTTL} {
+		if d != "" {
+			if _, err := time.ParseDuration(string(d)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if _, err := c.checks().compile(); err != nil {
+		return err
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	return nil
+}
+
+func newProbers(cluster DNSCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithTimeout(cluster.Timeout.ToDuration(2 * time.Second)),
+		WithRegion(cluster.Region),
+		WithTLS(cluster.TLS),
+		WithChecks(cluster.checks()),
+	}
+	if cluster.QueryType != "" {
+		opts = append(opts, WithQueryType(cluster.QueryType))
+	}
+	if cluster.Transport != "" {
+		opts = append(opts, WithTransport(cluster.Transport))
+	}
+	p := NewDNSProbe(cluster.Servers, cluster.QueryName, opts...)
+	return []probe.Prober{p}, nil
+}
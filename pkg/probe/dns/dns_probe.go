@@ -0,0 +1,321 @@
+// Package dns probes DNS servers by querying them for a name and checking
+// the answer.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+// Transports.
+const (
+	TransportUDP = "udp"
+	TransportTCP = "tcp"
+	TransportTLS = "tls" // DNS over TLS
+)
+
+var (
+	lookupDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prober_dns_lookup_duration_seconds",
+		Help:    "Round trip of the DNS query to each server",
+		Buckets: prometheus.DefBuckets,
+	}, "server")
+	serverUp = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_dns_server_up",
+		Help: "Whether the last query to each DNS server passed the checks",
+	}, "server")
+	answers = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_dns_answers",
+		Help: "Number of records in the answer section of the last response of each DNS server",
+	}, "server")
+)
+
+// Checks are the checks on a DNS response.
+type Checks struct {
+	// ValidRcodes are the accepted response codes, e.g. NOERROR or
+	// NXDOMAIN (default NOERROR).
+	ValidRcodes []string
+	// MinAnswers and MaxAnswers bound the number of answer records;
+	// MaxAnswers 0 is no limit.
+	MinAnswers int
+	MaxAnswers int
+	// Every regex of AnswerMatches must match an answer record and none of
+	// AnswerNotMatches may match any, in zone file format, e.g.
+	// "example.com.\t300\tIN\tA\t192.0.2.1".
+	AnswerMatches    []string
+	AnswerNotMatches []string
+	// MinTTL and MaxTTL bound the TTL of every answer record; 0 is no
+	// bound.
+	MinTTL time.Duration
+	MaxTTL time.Duration
+	// RequireAD sets the AD bit in the query and fails when the response
+	// isn't authenticated by a validating resolver (DNSSEC).
+	RequireAD bool
+}
+
+type compiledChecks struct {
+	Checks
+	rcodes     map[int]bool
+	matches    []*regexp.Regexp
+	notMatches []*regexp.Regexp
+}
+
+func (c Checks) compile() (*compiledChecks, error) {
+	cc := &compiledChecks{Checks: c, rcodes: make(map[int]bool)}
+	rcodes := c.ValidRcodes
+	if len(rcodes) == 0 {
+		rcodes = []string{"NOERROR"}
+	}
+	for _, name := range rcodes {
+		rcode, ok := mdns.StringToRcode[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("validRcodes: unknown rcode %q", name)
+		}
+		cc.rcodes[rcode] = true
+	}
+	if c.MinAnswers < 0 || c.MaxAnswers < 0 || (c.MaxAnswers > 0 && c.MaxAnswers < c.MinAnswers) {
+		return nil, fmt.Errorf("invalid answer count bounds %d-%d", c.MinAnswers, c.MaxAnswers)
+	}
+	if c.MaxTTL > 0 && c.MaxTTL < c.MinTTL {
+		return nil, fmt.Errorf("maxTTL %s is below minTTL %s", c.MaxTTL, c.MinTTL)
+	}
+	for i, expr := range c.AnswerMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("answerMatches[%d]: %w", i, err)
+		}
+		cc.matches = append(cc.matches, re)
+	}
+	for i, expr := range c.AnswerNotMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("answerNotMatches[%d]: %w", i, err)
+		}
+		cc.notMatches = append(cc.notMatches, re)
+	}
+	return cc, nil
+}
+
+// check applies the checks to a response.
+func (c *compiledChecks) check(resp *mdns.Msg) error {
+	if !c.rcodes[resp.Rcode] {
+		return fmt.Errorf("rcode %s", mdns.RcodeToString[resp.Rcode])
+	}
+	n := len(resp.Answer)
+	if n < c.MinAnswers {
+		return fmt.Errorf("%d answers, want at least %d", n, c.MinAnswers)
+	}
+	if c.MaxAnswers > 0 && n > c.MaxAnswers {
+		return fmt.Errorf("%d answers, want at most %d", n, c.MaxAnswers)
+	}
+	records := make([]string, n)
+	for i, rr := range resp.Answer {
+		records[i] = rr.String()
+		ttl := time.Duration(rr.Header().Ttl) * time.Second
+		if ttl < c.MinTTL {
+			return fmt.Errorf("TTL %s below %s: %s", ttl, c.MinTTL, records[i])
+		}
+		if c.MaxTTL > 0 && ttl > c.MaxTTL {
+			return fmt.Errorf("TTL %s above %s: %s", ttl, c.MaxTTL, records[i])
+		}
+	}
+	for _, re := range c.matches {
+		if !matchesAny(re, records) {
+			return fmt.Errorf("no answer matches %q", re)
+		}
+	}
+	for _, re := range c.notMatches {
+		if matchesAny(re, records) {
+			return fmt.Errorf("an answer matches %q", re)
+		}
+	}
+	if c.RequireAD && !resp.AuthenticatedData {
+		return fmt.Errorf("response is not authenticated (AD bit not set)")
+	}
+	return nil
+}
+
+func matchesAny(re *regexp.Regexp, records []string) bool {
+	for _, r := range records {
+		if re.MatchString(r) {
+			return true
+		}
+	}
+	return false
+}
+
+type DNSProbe struct {
+	Servers   []string
+	QueryName string
+	// QueryType is a record type such as A, AAAA, MX or TXT (default A).
+	QueryType string
+	// Transport is TransportUDP (default), TransportTCP or TransportTLS.
+	// Truncated UDP responses are retried over TCP.
+	Transport string
+	// TLS configures DNS over TLS; the server name defaults to the host of
+	// the server.
+	TLS     tlsconfig.Config
+	Timeout time.Duration
+	Region  string
+	Checks  Checks
+
+	checks *compiledChecks
+}
+
+// Option configures a DNSProbe.
+type Option func(*DNSProbe)
+
+// WithQueryType sets the record type to query (default A).
+func WithQueryType(qtype string) Option {
+	return func(p *DNSProbe) { p.QueryType = qtype }
+}
+
+// WithTransport sets the transport: TransportUDP, TransportTCP or
+// TransportTLS.
+func WithTransport(transport string) Option {
+	return func(p *DNSProbe) { p.Transport = transport }
+}
+
+// WithTLS sets the TLS settings for DNS over TLS.
+func WithTLS(c tlsconfig.Config) Option {
+	return func(p *DNSProbe) { p.TLS = c }
+}
+
+// WithTimeout sets the query timeout per server (default 2s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *DNSProbe) { p.Timeout = timeout }
+}
+
+// WithRegion sets the region of the probed servers.
+func WithRegion(region string) Option {
+	return func(p *DNSProbe) { p.Region = region }
+}
+
+// WithChecks sets the checks on the response.
+func WithChecks(c Checks) Option {
+	return func(p *DNSProbe) { p.Checks = c }
+}
+
+// NewDNSProbe creates a DNSProbe that queries every server for name.
+func NewDNSProbe(servers []string, name string, opts ...Option) *DNSProbe {
+	p := &DNSProbe{
+		Servers:   servers,
+		QueryName: name,
+		QueryType: "A",
+		Transport: TransportUDP,
+		Timeout:   2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// parseType returns the numeric record type of qtype.
+func parseType(qtype string) (uint16, error) {
+	t, ok := mdns.StringToType[strings.ToUpper(qtype)]
+	if !ok {
+		return 0, fmt.Errorf("unknown record type %q", qtype)
+	}
+	return t, nil
+}
+
+// serverAddress adds the default port of the transport to a server without
+// one.
+func serverAddress(server, transport string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	port := "53"
+	if transport == TransportTLS {
+		port = "853"
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), port)
+}
+
+// Probe implements the Prober interface. The servers are queried
+// concurrently and all of them must pass.
+func (p *DNSProbe) Probe(ctx context.Context) error {
+	qtype, err := parseType(p.QueryType)
+	if err != nil {
+		return err
+	}
+	if p.checks == nil {
+		checks, err := p.Checks.compile()
+		if err != nil {
+			return err
+		}
+		p.checks = checks
+	}
+	errs := make([]error, len(p.Servers))
+	var wg sync.WaitGroup
+	for i, server := range p.Servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.query(ctx, server, qtype)
+			up := 0.0
+			if errs[i] == nil {
+				up = 1
+			}
+			serverUp.Set(ctx, up, server)
+		}()
+	}
+	wg.Wait()
+	var failed []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("dns probe errors: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// query sends the question to server and checks the response.
+func (p *DNSProbe) query(ctx context.Context, server string, qtype uint16) error {
+	msg := new(mdns.Msg)
+	msg.SetQuestion(mdns.Fqdn(p.QueryName), qtype)
+	msg.AuthenticatedData = p.Checks.RequireAD
+	addr := serverAddress(server, p.Transport)
+
+	client := &mdns.Client{Timeout: p.Timeout}
+	switch p.Transport {
+	case TransportTCP:
+		client.Net = "tcp"
+	case TransportTLS:
+		client.Net = "tcp-tls"
+		client.TLSConfig = p.TLS.ClientConfigFor(tlsconfig.ServerName(addr))
+	}
+	resp, rtt, err := client.ExchangeContext(ctx, msg, addr)
+	if err == nil && resp.Truncated && client.Net == "" {
+		client.Net = "tcp"
+		resp, rtt, err = client.ExchangeContext(ctx, msg, addr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: query error: %v", server, err)
+	}
+	lookupDuration.Observe(ctx, rtt.Seconds(), server)
+	answers.Set(ctx, float64(len(resp.Answer)), server)
+	probe.AddDetail(ctx, server, fmt.Sprintf("%s, %d answers in %s", mdns.RcodeToString[resp.Rcode], len(resp.Answer), rtt.Round(time.Microsecond)))
+	if err := p.checks.check(resp); err != nil {
+		return fmt.Errorf("%s: %v", server, err)
+	}
+	return nil
+}
+
+func (p *DNSProbe) MetadataString() string {
+	return fmt.Sprintf("servers: %v , query: %s %s , transport: %s , region: %s", p.Servers, p.QueryName, p.QueryType, p.Transport, p.Region)
+}
@@ -0,0 +1,213 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// testZone answers the test queries:
+//
+//	a.example.     two A records with TTL 300
+//	nx.example.    NXDOMAIN
+//	signed.example one A record with the AD bit set
+//	big.example.   truncated over UDP, three A records over TCP
+func testZone(w mdns.ResponseWriter, req *mdns.Msg) {
+	resp := new(mdns.Msg)
+	resp.SetReply(req)
+	a := func(ip string, ttl uint32) mdns.RR {
+		return &mdns.A{
+			Hdr: mdns.RR_Header{Name: req.Question[0].Name, Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: ttl},
+			A:   net.ParseIP(ip),
+		}
+	}
+	switch req.Question[0].Name {
+	case "a.example.":
+		resp.Answer = []mdns.RR{a("192.0.2.1", 300), a("192.0.2.2", 300)}
+	case "nx.example.":
+		resp.Rcode = mdns.RcodeNameError
+	case "signed.example.":
+		resp.Answer = []mdns.RR{a("192.0.2.3", 60)}
+		resp.AuthenticatedData = true
+	case "big.example.":
+		if w.RemoteAddr().Network() == "udp" {
+			resp.Truncated = true
+		} else {
+			resp.Answer = []mdns.RR{a("192.0.2.4", 60), a("192.0.2.5", 60), a("192.0.2.6", 60)}
+		}
+	default:
+		resp.Rcode = mdns.RcodeRefused
+	}
+	w.WriteMsg(resp)
+}
+
+// startServer serves testZone over UDP and TCP on the same 127.0.0.1 port.
+func startServer(t *testing.T) string {
+	t.Helper()
+	var pc net.PacketConn
+	var l net.Listener
+	for i := 0; ; i++ {
+		var err error
+		if pc, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		if l, err = net.Listen("tcp", pc.LocalAddr().String()); err == nil {
+			break
+		}
+		pc.Close()
+		if i == 10 {
+			t.Fatalf("no free UDP and TCP port pair: %v", err)
+		}
+	}
+	for _, srv := range []*mdns.Server{
+		{PacketConn: pc, Handler: mdns.HandlerFunc(testZone)},
+		{Listener: l, Handler: mdns.HandlerFunc(testZone)},
+	} {
+		started := make(chan struct{})
+		srv.NotifyStartedFunc = func() { close(started) }
+		go srv.ActivateAndServe()
+		<-started
+		t.Cleanup(func() { srv.Shutdown() })
+	}
+	return pc.LocalAddr().String()
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestProbeChecks(t *testing.T) {
+	server := startServer(t)
+	tests := []struct {
+		name    string
+		cluster DNSCluster
+		wantErr string
+	}{
+		{
+			name:    "default checks",
+			cluster: DNSCluster{QueryName: "a.example"},
+		},
+		{
+			name:    "unexpected rcode",
+			cluster: DNSCluster{QueryName: "nx.example"},
+			wantErr: "rcode NXDOMAIN",
+		},
+		{
+			name:    "accepted NXDOMAIN",
+			cluster: DNSCluster{QueryName: "nx.example", ValidRcodes: []string{"nxdomain"}, MinAnswers: intPtr(0)},
+		},
+		{
+			name:    "too few answers",
+			cluster: DNSCluster{QueryName: "a.example", MinAnswers: intPtr(3)},
+			wantErr: "2 answers, want at least 3",
+		},
+		{
+			name:    "too many answers",
+			cluster: DNSCluster{QueryName: "a.example", MaxAnswers: 1},
+			wantErr: "2 answers, want at most 1",
+		},
+		{
+			name:    "answer matches",
+			cluster: DNSCluster{QueryName: "a.example", AnswerMatches: []string{`\tA\t192\.0\.2\.2$`}},
+		},
+		{
+			name:    "no answer matches",
+			cluster: DNSCluster{QueryName: "a.example", AnswerMatches: []string{`192\.0\.2\.9`}},
+			wantErr: "no answer matches",
+		},
+		{
+			name:    "answer must not match",
+			cluster: DNSCluster{QueryName: "a.example", AnswerNotMatches: []string{`192\.0\.2\.1$`}},
+			wantErr: "an answer matches",
+		},
+		{
+			name:    "TTL within bounds",
+			cluster: DNSCluster{QueryName: "a.example", MinTTL: "1m", MaxTTL: "10m"},
+		},
+		{
+			name:    "TTL below minimum",
+			cluster: DNSCluster{QueryName: "a.example", MinTTL: "10m"},
+			wantErr: "below",
+		},
+		{
+			name:    "TTL above maximum",
+			cluster: DNSCluster{QueryName: "a.example", MaxTTL: "1m"},
+			wantErr: "above",
+		},
+		{
+			name:    "requireAD with authenticated response",
+			cluster: DNSCluster{QueryName: "signed.example", RequireAD: true},
+		},
+		{
+			name:    "requireAD with unauthenticated response",
+			cluster: DNSCluster{QueryName: "a.example", RequireAD: true},
+			wantErr: "not authenticated",
+		},
+		{
+			name:    "truncated UDP response retried over TCP",
+			cluster: DNSCluster{QueryName: "big.example", MinAnswers: intPtr(3)},
+		},
+		{
+			name:    "TCP transport",
+			cluster: DNSCluster{QueryName: "big.example", Transport: TransportTCP, MinAnswers: intPtr(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cluster.Servers = []string{server}
+			tt.cluster.Timeout = "1s"
+			if err := tt.cluster.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			probers, err := newProbers(tt.cluster)
+			if err != nil {
+				t.Fatalf("newProbers: %v", err)
+			}
+			err = probers[0].Probe(context.Background())
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Probe: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("Probe succeeded, want error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("Probe error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeUnreachableServer(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	// Nothing answers on pc, so the query times out.
+	p := NewDNSProbe([]string{startServer(t), pc.LocalAddr().String()}, "a.example", WithTimeout(200*time.Millisecond))
+	err = p.Probe(context.Background())
+	if err == nil {
+		t.Fatal("Probe succeeded with an unreachable server")
+	}
+	if !strings.Contains(err.Error(), pc.LocalAddr().String()) {
+		t.Errorf("error %q does not name the unreachable server", err)
+	}
+}
+
+func TestServerAddress(t *testing.T) {
+	tests := []struct {
+		server, transport, want string
+	}{
+		{"10.0.0.1", TransportUDP, "10.0.0.1:53"},
+		{"10.0.0.1:5353", TransportUDP, "10.0.0.1:5353"},
+		{"dns.example", TransportTLS, "dns.example:853"},
+		{"[2001:db8::1]", TransportTCP, "[2001:db8::1]:53"},
+		{"2001:db8::1", TransportTLS, "[2001:db8::1]:853"},
+	}
+	for _, tt := range tests {
+		if got := serverAddress(tt.server, tt.transport); got != tt.want {
+			t.Errorf("serverAddress(%q, %q) = %q, want %q", tt.server, tt.transport, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// defaultMaxBodyBytes caps how much of the body is read for assertions when
+// no MaxBodyBytes is configured.
+const defaultMaxBodyBytes = 10 << 20
+
+// Assertions are checks on the response, run after the status code check.
+type Assertions struct {
+	// MaxBodyBytes fails the probe when the body is larger (0: no limit,
+	// although at most 10 MiB is read).
+	MaxBodyBytes   int64           `yaml:"maxBodyBytes"`
+	BodyMatches    []string        `yaml:"bodyMatches"`
+	BodyNotMatches []string        `yaml:"bodyNotMatches"`
+	JSON           []JSONAssertion `yaml:"json"`
+	// Headers maps a response header name to a regex its value must match.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// JSONAssertion checks a value in a JSON response body, selected by either a
+// JSONPath or a JMESPath expression. With neither Equals nor Matches set, the
+// value only has to exist.
+type JSONAssertion struct {
+	JSONPath string  `yaml:"jsonPath"`
+	JMESPath string  `yaml:"jmesPath"`
+	Equals   *string `yaml:"equals"`
+	Matches  string  `yaml:"matches"`
+}
+
+// AssertionError reports which assertion rule failed.
+type AssertionError struct {
+	Rule    string
+	Message string
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("assertion %s failed: %s", e.Rule, e.Message)
+}
+
+func (a *Assertions) empty() bool {
+	return a.MaxBodyBytes == 0 && len(a.BodyMatches) == 0 && len(a.BodyNotMatches) == 0 && len(a.JSON) == 0 && len(a.Headers) == 0
+}
+
+func (a *Assertions) needsBody() bool {
+	return a.MaxBodyBytes > 0 || len(a.BodyMatches) > 0 || len(a.BodyNotMatches) > 0 || len(a.JSON) > 0
+}
+
+type compiledJSON struct {
+	JSONAssertion
+	rule    string
+	jmes    *jmespath.JMESPath
+	matches *regexp.Regexp
+}
+
+type compiledAssertions struct {
+	maxBodyBytes   int64
+	bodyMatches    []*regexp.Regexp
+	bodyNotMatches []*regexp.Regexp
+	json           []compiledJSON
+	headers        map[string]*regexp.Regexp
+}
+
+// compile checks and compiles the assertions. It is run when the config is
+// validated and again on the first probe.
+func (a *Assertions) compile() (*compiledAssertions, error) {
+	c := &compiledAssertions{maxBodyBytes: a.MaxBodyBytes, headers: make(map[string]*regexp.Regexp)}
+	if a.MaxBodyBytes < 0 {
+		return nil, errors.New("maxBodyBytes must not be negative")
+	}
+	for i, expr := range a.BodyMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("bodyMatches[%d]: %w", i, err)
+		}
+		c.bodyMatches = append(c.bodyMatches, re)
+	}
+	for i, expr := range a.BodyNotMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("bodyNotMatches[%d]: %w", i, err)
+		}
+		c.bodyNotMatches = append(c.bodyNotMatches, re)
+	}
+	for i, ja := range a.JSON {
+		cj := compiledJSON{JSONAssertion: ja}
+		switch {
+		case ja.JSONPath != "" && ja.JMESPath != "":
+			return nil, fmt.Errorf("json[%d]: set only one of jsonPath and jmesPath", i)
+		case ja.JSONPath != "":
+			if _, err := parseJSONPath(ja.JSONPath); err != nil {
+				return nil, fmt.Errorf("json[%d]: %w", i, err)
+			}
+			cj.rule = fmt.Sprintf("json[%d] (%s)", i, ja.JSONPath)
+		case ja.JMESPath != "":
+			jp, err := jmespath.Compile(ja.JMESPath)
+			if err != nil {
+				return nil, fmt.Errorf("json[%d]: jmesPath %q: %w", i, ja.JMESPath, err)
+			}
+			cj.jmes = jp
+			cj.rule = fmt.Sprintf("json[%d] (%s)", i, ja.JMESPath)
+		default:
+			return nil, fmt.Errorf("json[%d]: jsonPath or jmesPath is required", i)
+		}
+		if ja.Matches != "" {
+			re, err := regexp.Compile(ja.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("json[%d]: %w", i, err)
+			}
+			cj.matches = re
+		}
+		c.json = append(c.json, cj)
+	}
+	for name, expr := range a.Headers {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("headers[%s]: %w", name, err)
+		}
+		c.headers[name] = re
+	}
+	return c, nil
+}
+
+// check runs the assertions against a response whose body has been read into
+// body. truncated is set when the body exceeded the read limit.
+func (c *compiledAssertions) check(header http.Header, body []byte, truncated bool) error {
+	if c.maxBodyBytes > 0 && (truncated || int64(len(body)) > c.maxBodyBytes) {
+		return &AssertionError{Rule: "maxBodyBytes", Message: fmt.Sprintf("body exceeds %d bytes", c.maxBodyBytes)}
+	}
+	for name, re := range c.headers {
+		values := header.Values(name)
+		if len(values) == 0 {
+			return &AssertionError{Rule: "headers[" + name + "]", Message: "header missing"}
+		}
+		matched := false
+		for _, v := range values {
+			if re.MatchString(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return &AssertionError{Rule: "headers[" + name + "]", Message: fmt.Sprintf("value %q does not match %q", values[0], re)}
+		}
+	}
+	for i, re := range c.bodyMatches {
+		if !re.Match(body) {
+			return &AssertionError{Rule: fmt.Sprintf("bodyMatches[%d]", i), Message: fmt.Sprintf("body does not match %q", re)}
+		}
+	}
+	for i, re := range c.bodyNotMatches {
+		if re.Match(body) {
+			return &AssertionError{Rule: fmt.Sprintf("bodyNotMatches[%d]", i), Message: fmt.Sprintf("body matches %q", re)}
+		}
+	}
+	if len(c.json) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return &AssertionError{Rule: c.json[0].rule, Message: "body is not valid JSON: " + err.Error()}
+	}
+	for _, cj := range c.json {
+		if err := cj.check(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cj *compiledJSON) check(doc interface{}) error {
+	var value interface{}
+	found := true
+	if cj.jmes != nil {
+		v, err := cj.jmes.Search(doc)
+		if err != nil {
+			return &AssertionError{Rule: cj.rule, Message: err.Error()}
+		}
+		value, found = v, v != nil
+	} else {
+		v, ok, err := evalJSONPath(doc, cj.JSONPath)
+		if err != nil {
+			return &AssertionError{Rule: cj.rule, Message: err.Error()}
+		}
+		value, found = v, ok
+	}
+	if !found {
+		return &AssertionError{Rule: cj.rule, Message: "no value found"}
+	}
+	got := jsonValueString(value)
+	if cj.Equals != nil && got != *cj.Equals {
+		return &AssertionError{Rule: cj.rule, Message: fmt.Sprintf("got %q, want %q", got, *cj.Equals)}
+	}
+	if cj.matches != nil && !cj.matches.MatchString(got) {
+		return &AssertionError{Rule: cj.rule, Message: fmt.Sprintf("value %q does not match %q", got, cj.matches)}
+	}
+	return nil
+}
+
+// jsonValueString renders a JSON value for comparison: strings as-is, other
+// values in their JSON encoding (e.g. 1, true, null, {"a":1}).
+func jsonValueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []interface{}
+		wantErr bool
+	}{
+		{path: "$", want: nil},
+		{path: "$.status", want: []interface{}{"status"}},
+		{path: "$.items[0].name", want: []interface{}{"items", 0, "name"}},
+		{path: "$['a.b'][\"c\"]", want: []interface{}{"a.b", "c"}},
+		{path: "$.items[-1]", want: []interface{}{"items", -1}},
+		{path: "status", wantErr: true},
+		{path: "$..status", wantErr: true},
+		{path: "$.items[0", wantErr: true},
+		{path: "$.items[*]", wantErr: true},
+		{path: "$x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEvalJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"status": "ok",
+		"items":  []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}},
+		"count":  float64(2),
+	}
+	tests := []struct {
+		path      string
+		want      interface{}
+		wantFound bool
+	}{
+		{"$.status", "ok", true},
+		{"$.items[1].name", "b", true},
+		{"$.items[-1]['name']", "b", true},
+		{"$.count", float64(2), true},
+		{"$.missing", nil, false},
+		{"$.items[5]", nil, false},
+		{"$.status.inner", nil, false},
+		{"$.items.name", nil, false},
+	}
+	for _, tt := range tests {
+		got, found, err := evalJSONPath(doc, tt.path)
+		if err != nil {
+			t.Errorf("evalJSONPath(%q): %v", tt.path, err)
+			continue
+		}
+		if found != tt.wantFound || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("evalJSONPath(%q) = %v, %v, want %v, %v", tt.path, got, found, tt.want, tt.wantFound)
+		}
+	}
+}
+
+func TestAssertionsCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Assertions
+	}{
+		{"negative maxBodyBytes", Assertions{MaxBodyBytes: -1}},
+		{"bad bodyMatches", Assertions{BodyMatches: []string{"("}}},
+		{"bad bodyNotMatches", Assertions{BodyNotMatches: []string{"["}}},
+		{"bad header regex", Assertions{Headers: map[string]string{"X": "("}}},
+		{"json without path", Assertions{JSON: []JSONAssertion{{}}}},
+		{"json with both paths", Assertions{JSON: []JSONAssertion{{JSONPath: "$.a", JMESPath: "a"}}}},
+		{"bad jsonPath", Assertions{JSON: []JSONAssertion{{JSONPath: "a"}}}},
+		{"bad jmesPath", Assertions{JSON: []JSONAssertion{{JMESPath: "a[?"}}}},
+		{"bad json matches", Assertions{JSON: []JSONAssertion{{JSONPath: "$.a", Matches: "("}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.a.compile(); err == nil {
+				t.Error("compile accepted invalid assertions")
+			}
+		})
+	}
+}
+
+func TestAssertionsCheck(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}, "X-Version": {"1.2.3"}}
+	body := []byte(`{"status":"ok","healthy":true,"items":[{"id":1},{"id":2}],"meta":null}`)
+	tests := []struct {
+		name      string
+		a         Assertions
+		truncated bool
+		wantRule  string // empty: the check passes
+	}{
+		{name: "no assertions"},
+		{name: "body matches", a: Assertions{BodyMatches: []string{`"status":"ok"`}}},
+		{name: "body does not match", a: Assertions{BodyMatches: []string{`"status":"down"`}}, wantRule: "bodyMatches[0]"},
+		{name: "body must not match", a: Assertions{BodyNotMatches: []string{`healthy":true`}}, wantRule: "bodyNotMatches[0]"},
+		{name: "body size within limit", a: Assertions{MaxBodyBytes: 1000}},
+		{name: "body too large", a: Assertions{MaxBodyBytes: 10}, wantRule: "maxBodyBytes"},
+		{name: "body truncated", a: Assertions{MaxBodyBytes: 1000}, truncated: true, wantRule: "maxBodyBytes"},
+		{name: "header matches", a: Assertions{Headers: map[string]string{"x-version": `^1\.`}}},
+		{name: "header does not match", a: Assertions{Headers: map[string]string{"X-Version": `^2\.`}}, wantRule: "headers[X-Version]"},
+		{name: "header missing", a: Assertions{Headers: map[string]string{"X-Missing": "."}}, wantRule: "headers[X-Missing]"},
+		{name: "jsonPath equals", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.status", Equals: strPtr("ok")}}}},
+		{name: "jsonPath equals non-string", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.healthy", Equals: strPtr("true")}}}},
+		{name: "jsonPath null equals", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.meta", Equals: strPtr("null")}}}},
+		{name: "jsonPath differs", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.status", Equals: strPtr("down")}}}, wantRule: "json[0] ($.status)"},
+		{name: "jsonPath exists", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.items[1].id"}}}},
+		{name: "jsonPath missing", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.items[2]"}}}, wantRule: "json[0] ($.items[2])"},
+		{name: "jsonPath matches", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.items[0]", Matches: `"id":1`}}}},
+		{name: "jmesPath equals", a: Assertions{JSON: []JSONAssertion{{JMESPath: "length(items)", Equals: strPtr("2")}}}},
+		{name: "jmesPath missing", a: Assertions{JSON: []JSONAssertion{{JMESPath: "missing"}}}, wantRule: "json[0] (missing)"},
+		{name: "second json assertion fails", a: Assertions{JSON: []JSONAssertion{{JSONPath: "$.status"}, {JSONPath: "$.healthy", Equals: strPtr("false")}}}, wantRule: "json[1] ($.healthy)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.a.compile()
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			err = c.check(header, body, tt.truncated)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("check: %v", err)
+				}
+				return
+			}
+			var ae *AssertionError
+			if !errors.As(err, &ae) {
+				t.Fatalf("check error = %v, want an AssertionError", err)
+			}
+			if ae.Rule != tt.wantRule {
+				t.Errorf("failed rule = %q, want %q", ae.Rule, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestAssertionsCheckInvalidJSON(t *testing.T) {
+	c, err := (&Assertions{JSON: []JSONAssertion{{JSONPath: "$.a"}}}).compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.check(http.Header{}, []byte("<html>"), false)
+	if err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("check error = %v, want invalid JSON", err)
+	}
+}
@@ -0,0 +1,225 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// BasicAuth is the `basicAuth:` block of an HTTP cluster.
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// PasswordFile is read on every probe, so the password can be rotated
+	// without a config change.
+	PasswordFile string `yaml:"passwordFile"`
+}
+
+// OAuth2 is the `oauth2:` block of an HTTP cluster: a client-credentials
+// grant against TokenURL. The token is cached until shortly before it
+// expires.
+type OAuth2 struct {
+	ClientID         string            `yaml:"clientID"`
+	ClientSecret     string            `yaml:"clientSecret"`
+	ClientSecretFile string            `yaml:"clientSecretFile"`
+	TokenURL         string            `yaml:"tokenURL"`
+	Scopes           []string          `yaml:"scopes"`
+	EndpointParams   map[string]string `yaml:"endpointParams"`
+}
+
+// AuthError reports a failure to authenticate: the credentials could not be
+// read or fetched, or the server answered 401 or 403.
+type AuthError struct {
+	Method string // basic, bearer or oauth2
+	Err    error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s auth failed: %v", e.Method, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+func (b *BasicAuth) validate() error {
+	if b.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if b.Password != "" && b.PasswordFile != "" {
+		return fmt.Errorf("password and passwordFile are mutually exclusive")
+	}
+	return nil
+}
+
+func (o *OAuth2) validate() error {
+	if o.ClientID == "" || o.TokenURL == "" {
+		return fmt.Errorf("clientID and tokenURL are required")
+	}
+	if o.ClientSecret != "" && o.ClientSecretFile != "" {
+		return fmt.Errorf("clientSecret and clientSecretFile are mutually exclusive")
+	}
+	return nil
+}
+
+// validateAuth checks that at most one auth method is set and that it is
+// complete.
+func validateAuth(basic *BasicAuth, bearerTokenFile string, oauth *OAuth2) error {
+	n := 0
+	if basic != nil {
+		n++
+		if err := basic.validate(); err != nil {
+			return fmt.Errorf("basicAuth: %w", err)
+		}
+	}
+	if bearerTokenFile != "" {
+		n++
+	}
+	if oauth != nil {
+		n++
+		if err := oauth.validate(); err != nil {
+			return fmt.Errorf("oauth2: %w", err)
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("basicAuth, bearerTokenFile and oauth2 are mutually exclusive")
+	}
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// authMethod returns the configured auth method, or "" for none.
+func (p *HTTPProbe) authMethod() string {
+	switch {
+	case p.BasicAuth != nil:
+		return "basic"
+	case p.BearerTokenFile != "":
+		return "bearer"
+	case p.OAuth2 != nil:
+		return "oauth2"
+	}
+	return ""
+}
+
+// authenticate adds the credentials to req.
+func (p *HTTPProbe) authenticate(req *http.Request) error {
+	switch p.authMethod() {
+	case "basic":
+		password := p.BasicAuth.Password
+		if p.BasicAuth.PasswordFile != "" {
+			var err error
+			if password, err = readSecretFile(p.BasicAuth.PasswordFile); err != nil {
+				return &AuthError{Method: "basic", Err: err}
+			}
+		}
+		req.SetBasicAuth(p.BasicAuth.Username, password)
+	case "bearer":
+		token, err := readSecretFile(p.BearerTokenFile)
+		if err != nil {
+			return &AuthError{Method: "bearer", Err: err}
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case "oauth2":
+		token, err := p.oauth2Token()
+		if err != nil {
+			return &AuthError{Method: "oauth2", Err: err}
+		}
+		token.SetAuthHeader(req)
+	}
+	return nil
+}
+
+// oauth2Source caches the token of an OAuth2 config. It is rebuilt when the
+// client secret file changes.
+type oauth2Source struct {
+	mu        sync.Mutex
+	secret    string
+	source    oauth2.TokenSource
+	transport *http.Transport
+}
+
+// close releases the idle connections of the token client.
+func (s *oauth2Source) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+}
+
+// tokenTransport returns the transport of the token requests, with the
+// probe's proxy, dialer and TLS settings.
+func (p *HTTPProbe) tokenTransport() (*http.Transport, error) {
+	proxy, err := p.proxy()
+	if err != nil {
+		return nil, err
+	}
+	dial := p.dialContext()
+	transport := &http.Transport{
+		Proxy:             proxy,
+		DialContext:       dial,
+		TLSClientConfig:   p.tlsConfig,
+		ForceAttemptHTTP2: true,
+		IdleConnTimeout:   90 * time.Second,
+	}
+	if p.ProxyURL == "" {
+		transport.DialTLSContext = p.dialTLSContext(dial, "h2", "http/1.1")
+	}
+	return transport, nil
+}
+
+func (p *HTTPProbe) oauth2Token() (*oauth2.Token, error) {
+	o := p.OAuth2
+	secret := o.ClientSecret
+	if o.ClientSecretFile != "" {
+		var err error
+		if secret, err = readSecretFile(o.ClientSecretFile); err != nil {
+			return nil, err
+		}
+	}
+	s := &p.tokens
+	s.mu.Lock()
+	if s.source == nil || s.secret != secret {
+		transport, err := p.tokenTransport()
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		params := make(map[string][]string, len(o.EndpointParams))
+		for k, v := range o.EndpointParams {
+			params[k] = []string{v}
+		}
+		cc := &clientcredentials.Config{
+			ClientID:       o.ClientID,
+			ClientSecret:   secret,
+			TokenURL:       o.TokenURL,
+			Scopes:         o.Scopes,
+			EndpointParams: params,
+		}
+		// The token source refreshes with the context it was created with,
+		// so it gets its own client rather than the probe's context.
+		client := &http.Client{Timeout: p.Timeout, Transport: transport}
+		s.source = cc.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, client))
+		s.secret = secret
+		if s.transport != nil {
+			s.transport.CloseIdleConnections()
+		}
+		s.transport = transport
+	}
+	source := s.source
+	s.mu.Unlock()
+	// Bounded by the client timeout; the token source takes no context.
+	return source.Token()
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlscheck"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type HTTPCluster struct {
+	probe.ClusterBase       `yaml:",inline"`
+	Endpoint                string               `yaml:"endpoint"`
+	Method                  string               `yaml:"method"`
+	Body                    string               `yaml:"body"`
+	Headers                 map[string]string    `yaml:"headers"`
+	ProxyURL                string               `yaml:"proxyURL"`
+	UnacceptableStatusCodes []int                `yaml:"unacceptableStatusCodes"`
+	ValidStatusCodes        []string             `yaml:"validStatusCodes"`
+	Redirects               string               `yaml:"redirects"`
+	Timeout                 probe.DurationString `yaml:"timeout"`
+	SkipTLSVerify           bool                 `yaml:"skipTLSVerify"`
+	Assertions              Assertions           `yaml:"assertions"`
+	TLS                     tlsconfig.Config     `yaml:"tls"`
+	TLSCheck                tlscheck.Config      `yaml:"tlsCheck"`
+	BasicAuth               *BasicAuth           `yaml:"basicAuth"`
+	BearerTokenFile         string               `yaml:"bearerTokenFile"`
+	OAuth2                  *OAuth2              `yaml:"oauth2"`
+	Connection              string               `yaml:"connection"`
+	Protocol                string               `yaml:"protocol"`
+	RequireProtocol         bool                 `yaml:"requireProtocol"`
+	IPProtocol              string               `yaml:"ipProtocol"`
+	SourceIP                string               `yaml:"sourceIP"`
+}
+
+// HTTPFlowCluster is an `httpFlow` cluster: requests run in order, passing
+// extracted values on through template variables.
+type HTTPFlowCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Vars              map[string]string    `yaml:"vars"`
+	Steps             []FlowStep           `yaml:"steps"`
+	ProxyURL          string               `yaml:"proxyURL"`
+	Timeout           probe.DurationString `yaml:"timeout"`
+	SkipTLSVerify     bool                 `yaml:"skipTLSVerify"`
+	TLS               tlsconfig.Config     `yaml:"tls"`
+	TLSCheck          tlscheck.Config      `yaml:"tlsCheck"`
+	Connection        string               `yaml:"connection"`
+}
+
+func init() {
+	probe.Register("http", newProbers)
+	probe.Register("httpFlow", newFlowProbers)
+}
+
+func (c HTTPCluster) Validate() error {
+	// Rendering once catches template errors and unset environment
+	// variables.
+	t, err := parseRequestTemplate("endpoint", c.Endpoint, c.Headers, c.Body)
+	if err != nil {
+		return err
+	}
+	endpoint, _, _, err := t.render(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := parseStatusCodes(c.ValidStatusCodes); err != nil {
+		return fmt.Errorf("validStatusCodes: %w", err)
+	}
+	if _, err := checkRedirectFunc(c.Redirects); err != nil {
+		return err
+	}
+	if _, err := c.Assertions.compile(); err != nil {
+		return fmt.Errorf("assertions: %w", err)
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if err := c.TLSCheck.Validate(); err != nil {
+		return fmt.Errorf("tlsCheck: %w", err)
+	}
+	if err := validateConnection(c.Connection); err != nil {
+		return err
+	}
+	if err := validateProtocol(c.Protocol, c.RequireProtocol, c.IPProtocol, c.SourceIP, endpoint, c.ProxyURL); err != nil {
+		return err
+	}
+	if err := validateAuth(c.BasicAuth, c.BearerTokenFile, c.OAuth2); err != nil {
+		return err
+	}
+	return nil
+}
+
+func newProbers(cluster HTTPCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithBody(cluster.Body),
+		WithProxyURL(cluster.ProxyURL),
+		WithHeaders(cluster.Headers),
+		WithUnacceptableStatusCodes(cluster.UnacceptableStatusCodes...),
+		WithValidStatusCodes(cluster.ValidStatusCodes...),
+		WithRedirects(cluster.Redirects),
+		WithTimeout(cluster.Timeout.ToDuration(2 * time.Second)),
+		WithSkipTLSVerify(cluster.SkipTLSVerify),
+		WithAssertions(cluster.Assertions),
+		WithTLS(cluster.TLS),
+		WithTLSCheck(cluster.TLSCheck),
+		WithConnection(cluster.Connection),
+		WithProtocol(cluster.Protocol, cluster.RequireProtocol),
+		WithIPProtocol(cluster.IPProtocol),
+		WithSourceIP(cluster.SourceIP),
+		WithRegion(cluster.Region),
+	}
+	if cluster.Method != "" {
+		opts = append(opts, WithMethod(cluster.Method))
+	}
+	if cluster.BasicAuth != nil {
+		opts = append(opts, WithBasicAuth(*cluster.BasicAuth))
+	}
+	if cluster.BearerTokenFile != "" {
+		opts = append(opts, WithBearerTokenFile(cluster.BearerTokenFile))
+	}
+	if cluster.OAuth2 != nil {
+		opts = append(opts, WithOAuth2(*cluster.OAuth2))
+	}
+	return []probe.Prober{NewHTTPProbe(cluster.Endpoint, opts...)}, nil
+}
+
+func validateConnection(mode string) error {
+	switch mode {
+	case "", ConnectionFresh, ConnectionKeepAlive:
+		return nil
+	}
+	return fmt.Errorf("connection: invalid mode %q, want %s or %s", mode, ConnectionFresh, ConnectionKeepAlive)
+}
+
+func (c HTTPFlowCluster) Validate() error {
+	if _, err := compileSteps(c.Steps, c.Vars); err != nil {
+		return err
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if err := c.TLSCheck.Validate(); err != nil {
+		return fmt.Errorf("tlsCheck: %w", err)
+	}
+	return validateConnection(c.Connection)
+}
+
+func newFlowProbers(cluster HTTPFlowCluster) ([]probe.Prober, error) {
+	p := NewFlowProbe(cluster.Steps, cluster.Vars,
+		WithProxyURL(cluster.ProxyURL),
+		WithTimeout(cluster.Timeout.ToDuration(2*time.Second)),
+		WithSkipTLSVerify(cluster.SkipTLSVerify),
+		WithTLS(cluster.TLS),
+		WithTLSCheck(cluster.TLSCheck),
+		WithConnection(cluster.Connection),
+		WithRegion(cluster.Region),
+	)
+	return []probe.Prober{p}, nil
+}
@@ -0,0 +1,349 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+var (
+	stepDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prober_http_flow_step_duration_seconds",
+		Help:    "Duration of each step of an HTTP flow",
+		Buckets: prometheus.DefBuckets,
+	}, "step")
+	stepFailures = probe.NewCounterVec(prometheus.CounterOpts{
+		Name: "prober_http_flow_step_failures_total",
+		Help: "HTTP flows that failed, by the step that failed",
+	}, "step")
+)
+
+// FlowStep is one request of an HTTP flow. URL, header values and body are
+// text/template strings over the flow variables, e.g. {{.token}}.
+type FlowStep struct {
+	Name             string            `yaml:"name"`
+	Method           string            `yaml:"method"`
+	URL              string            `yaml:"url"`
+	Headers          map[string]string `yaml:"headers"`
+	Body             string            `yaml:"body"`
+	ValidStatusCodes []string          `yaml:"validStatusCodes"`
+	Redirects        string            `yaml:"redirects"`
+	Assertions       Assertions        `yaml:"assertions"`
+	Extract          []Extractor       `yaml:"extract"`
+}
+
+// Extractor sets the flow variable Var from the response of a step, for the
+// steps after it. Exactly one of JSONPath, Regex and Header is set. A regex
+// yields its first capture group, or the whole match without one.
+type Extractor struct {
+	Var      string `yaml:"var"`
+	JSONPath string `yaml:"jsonPath"`
+	Regex    string `yaml:"regex"`
+	Header   string `yaml:"header"`
+}
+
+// ExtractError reports a value an extractor could not find.
+type ExtractError struct {
+	Var     string
+	Message string
+}
+
+func (e *ExtractError) Error() string {
+	return fmt.Sprintf("extract %s: %s", e.Var, e.Message)
+}
+
+// StepError reports which step of a flow failed.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %q failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// FlowProbe runs its steps in order with a shared client and cookie jar, and
+// fails on the first failing step. Variables start from Vars on every run.
+type FlowProbe struct {
+	Steps []FlowStep
+	Vars  map[string]string
+	// base holds the client settings shared by the steps.
+	base  *HTTPProbe
+	opts  []Option
+	steps []*compiledStep
+}
+
+type compiledStep struct {
+	FlowStep
+	*requestTemplate
+	extract []compiledExtractor
+	probe   *HTTPProbe
+}
+
+type compiledExtractor struct {
+	Extractor
+	regex *regexp.Regexp
+}
+
+// NewFlowProbe creates a FlowProbe. The options configure the client of all
+// steps, e.g. WithTimeout (per step), WithProxyURL, WithTLS or
+// WithConnection.
+func NewFlowProbe(steps []FlowStep, vars map[string]string, opts ...Option) *FlowProbe {
+	return &FlowProbe{
+		Steps: steps,
+		Vars:  vars,
+		base:  NewHTTPProbe("", opts...),
+		opts:  opts,
+	}
+}
+
+// compileSteps checks and compiles the steps. Each step is rendered once
+// with the variables defined before it, so a reference to an undefined
+// variable is reported here rather than on the first run.
+func compileSteps(steps []FlowStep, vars map[string]string) ([]*compiledStep, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("at least one step is required")
+	}
+	defined := make(map[string]string, len(vars))
+	for k := range vars {
+		defined[k] = ""
+	}
+	seen := make(map[string]bool, len(steps))
+	compiled := make([]*compiledStep, 0, len(steps))
+	for i, step := range steps {
+		if step.Name == "" {
+			return nil, fmt.Errorf("steps[%d]: name is required", i)
+		}
+		if seen[step.Name] {
+			return nil, fmt.Errorf("steps[%d]: duplicate name %q", i, step.Name)
+		}
+		seen[step.Name] = true
+		c, err := compileStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		if _, _, _, err := c.render(defined); err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		for _, e := range step.Extract {
+			defined[e.Var] = ""
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func compileStep(step FlowStep) (*compiledStep, error) {
+	if step.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	tmpl, err := parseRequestTemplate("url", step.URL, step.Headers, step.Body)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiledStep{FlowStep: step, requestTemplate: tmpl}
+	if _, err := parseStatusCodes(step.ValidStatusCodes); err != nil {
+		return nil, fmt.Errorf("validStatusCodes: %w", err)
+	}
+	if _, err := checkRedirectFunc(step.Redirects); err != nil {
+		return nil, err
+	}
+	if _, err := step.Assertions.compile(); err != nil {
+		return nil, fmt.Errorf("assertions: %w", err)
+	}
+	for i, e := range step.Extract {
+		ce, err := compileExtractor(e)
+		if err != nil {
+			return nil, fmt.Errorf("extract[%d]: %w", i, err)
+		}
+		c.extract = append(c.extract, ce)
+	}
+	return c, nil
+}
+
+func compileExtractor(e Extractor) (compiledExtractor, error) {
+	c := compiledExtractor{Extractor: e}
+	if e.Var == "" {
+		return c, fmt.Errorf("var is required")
+	}
+	n := 0
+	if e.JSONPath != "" {
+		n++
+		if _, err := parseJSONPath(e.JSONPath); err != nil {
+			return c, err
+		}
+	}
+	if e.Regex != "" {
+		n++
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return c, err
+		}
+		c.regex = re
+	}
+	if e.Header != "" {
+		n++
+	}
+	if n != 1 {
+		return c, fmt.Errorf("exactly one of jsonPath, regex and header is required")
+	}
+	return c, nil
+}
+
+// extractAll runs the extractors of the step on the response into vars.
+func (c *compiledStep) extractAll(vars map[string]string, resp *http.Response, body []byte) error {
+	var doc interface{}
+	decoded := false
+	for _, e := range c.extract {
+		switch {
+		case e.Header != "":
+			values := resp.Header.Values(e.Header)
+			if len(values) == 0 {
+				return &ExtractError{Var: e.Var, Message: fmt.Sprintf("header %s missing", e.Header)}
+			}
+			vars[e.Var] = values[0]
+		case e.regex != nil:
+			m := e.regex.FindSubmatch(body)
+			if m == nil {
+				return &ExtractError{Var: e.Var, Message: fmt.Sprintf("body does not match %q", e.Regex)}
+			}
+			if len(m) > 1 {
+				vars[e.Var] = string(m[1])
+			} else {
+				vars[e.Var] = string(m[0])
+			}
+		default:
+			if !decoded {
+				if err := json.Unmarshal(body, &doc); err != nil {
+					return &ExtractError{Var: e.Var, Message: fmt.Sprintf("body is not JSON: %v", err)}
+				}
+				decoded = true
+			}
+			v, found, err := evalJSONPath(doc, e.JSONPath)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return &ExtractError{Var: e.Var, Message: fmt.Sprintf("%s not found", e.JSONPath)}
+			}
+			vars[e.Var] = jsonValueString(v)
+		}
+	}
+	return nil
+}
+
+// setup compiles the steps and builds the client they share.
+func (p *FlowProbe) setup() error {
+	steps, err := compileSteps(p.Steps, p.Vars)
+	if err != nil {
+		return err
+	}
+	client, err := p.base.newClient()
+	if err != nil {
+		return err
+	}
+	p.base.client = client
+	for _, s := range steps {
+		opts := append([]Option{}, p.opts...)
+		opts = append(opts,
+			WithValidStatusCodes(s.ValidStatusCodes...),
+			WithRedirects(s.Redirects),
+			WithAssertions(s.Assertions),
+		)
+		if s.Method != "" {
+			opts = append(opts, WithMethod(s.Method))
+		}
+		sp := NewHTTPProbe("", opts...)
+		checkRedirect, err := checkRedirectFunc(s.Redirects)
+		if err != nil {
+			return err
+		}
+		sp.flowStep = true
+		sp.tlsConfig = p.base.tlsConfig
+		sp.tlsSettings = p.base.tlsSettings
+		sp.client = &http.Client{
+			Timeout:       client.Timeout,
+			Transport:     client.Transport,
+			CheckRedirect: checkRedirect,
+		}
+		s.probe = sp
+	}
+	p.steps = steps
+	return nil
+}
+
+func (p *FlowProbe) Probe(ctx context.Context) error {
+	if p.steps == nil {
+		if err := p.setup(); err != nil {
+			return err
+		}
+	}
+	vars := make(map[string]string, len(p.Vars))
+	for k, v := range p.Vars {
+		vars[k] = v
+	}
+	// Cookies set by one step are sent by the next ones, within a run.
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	timings := make([]string, 0, len(p.steps))
+	defer func() { probe.AddDetail(ctx, "Steps", strings.Join(timings, " ")) }()
+	for _, s := range p.steps {
+		start := time.Now()
+		err := p.runStep(ctx, s, vars, jar)
+		elapsed := time.Since(start)
+		stepDuration.Observe(ctx, elapsed.Seconds(), s.Name)
+		timings = append(timings, fmt.Sprintf("%s=%s", s.Name, elapsed.Round(time.Microsecond)))
+		if err != nil {
+			stepFailures.Inc(ctx, s.Name)
+			failures.Inc(ctx, failureReason(err))
+			probe.AddDetail(ctx, "Failed step", s.Name)
+			return &StepError{Step: s.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+func (p *FlowProbe) runStep(ctx context.Context, s *compiledStep, vars map[string]string, jar http.CookieJar) error {
+	url, headers, body, err := s.render(vars)
+	if err != nil {
+		return err
+	}
+	sp := s.probe
+	sp.Endpoint, sp.Headers, sp.Body = url, headers, body
+	sp.client.Jar = jar
+	if len(s.extract) > 0 {
+		sp.onResponse = func(resp *http.Response, body []byte) error {
+			return s.extractAll(vars, resp, body)
+		}
+	}
+	return sp.do(ctx)
+}
+
+// Close releases the connections of the flow's client and of the steps'
+// OAuth2 token clients.
+func (p *FlowProbe) Close() {
+	p.base.Close()
+	for _, s := range p.steps {
+		s.probe.Close()
+	}
+}
+
+func (p *FlowProbe) MetadataString() string {
+	names := make([]string, len(p.Steps))
+	for i, s := range p.Steps {
+		names[i] = s.Name
+	}
+	return fmt.Sprintf("Flow: %s , Proxy: %s , Region: %s", strings.Join(names, " -> "), p.base.ProxyURL, p.base.Region)
+}
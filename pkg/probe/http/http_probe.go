@@ -0,0 +1,452 @@
+// Package http probes HTTP(S) endpoints.
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlscheck"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+// Connection modes. A fresh connection per run includes DNS, connect and TLS
+// in every measurement; keep-alive reuses connections between runs to
+// measure steady-state request latency.
+const (
+	ConnectionFresh     = "fresh"
+	ConnectionKeepAlive = "keepAlive"
+)
+
+var failures = probe.NewCounterVec(prometheus.CounterOpts{
+	Name: "prober_http_failures_total",
+	Help: "Failed HTTP probes by reason: auth, status, assertion, protocol, extract or request",
+}, "reason")
+
+type HTTPProbe struct {
+	Region string
+	// Endpoint, Body and the header values are text/template strings,
+	// rendered on every run; see templateFuncs for the functions.
+	Endpoint                string
+	Method                  string
+	Body                    string
+	Headers                 map[string]string
+	ProxyURL                string
+	UnacceptableStatusCodes []int
+	// ValidStatusCodes are the accepted codes, classes ("2xx") or ranges
+	// ("200-299"). When neither list is set only 2xx is accepted.
+	ValidStatusCodes []string
+	// Redirects is "follow" (default), "none" or a maximum number of hops.
+	Redirects     string
+	Timeout       time.Duration
+	SkipTLSVerify bool
+	Assertions    Assertions
+	// TLSCheck is applied to HTTPS responses; certificate expiry and the
+	// negotiated version and cipher are exported either way.
+	TLSCheck tlscheck.Config
+	// TLS is the client TLS config for https URLs; SkipTLSVerify is kept as
+	// a shorthand for its InsecureSkipVerify.
+	TLS tlsconfig.Config
+	// At most one of BasicAuth, BearerTokenFile and OAuth2 is set.
+	BasicAuth       *BasicAuth
+	BearerTokenFile string
+	OAuth2          *OAuth2
+	// Connection is ConnectionFresh (default) or ConnectionKeepAlive.
+	Connection string
+	// Protocol is ProtocolHTTP1, ProtocolH2, ProtocolH2C or ProtocolH3;
+	// with RequireProtocol a response over another protocol fails.
+	Protocol        string
+	RequireProtocol bool
+	// IPProtocol is "ip4", "ip6" or "auto" (default) for resolving and
+	// dialing; SourceIP binds the local address.
+	IPProtocol string
+	SourceIP   string
+	closeConns func()
+	// flowStep and onResponse are set on the steps of an httpFlow: a step
+	// leaves the per-request metrics to the flow and hands the response to
+	// the flow's extractors.
+	flowStep   bool
+	onResponse func(resp *http.Response, body []byte) error
+	tokens     oauth2Source
+	tlsConfig  *tls.Config
+	// tlsSettings are TLS with SkipTLSVerify applied, for the TLS config
+	// of each connection.
+	tlsSettings tlsconfig.Config
+	client      *http.Client
+	templates   *requestTemplate
+	assertions  *compiledAssertions
+	validCodes  []statusRange
+}
+
+// Option configures an HTTPProbe.
+type Option func(*HTTPProbe)
+
+// WithMethod sets the request method (default GET).
+func WithMethod(method string) Option {
+	return func(p *HTTPProbe) { p.Method = method }
+}
+
+// WithBody sets the request body.
+func WithBody(body string) Option {
+	return func(p *HTTPProbe) { p.Body = body }
+}
+
+// WithHeaders sets the request headers.
+func WithHeaders(headers map[string]string) Option {
+	return func(p *HTTPProbe) { p.Headers = headers }
+}
+
+// WithProxyURL sends the request through the given proxy.
+func WithProxyURL(proxyURL string) Option {
+	return func(p *HTTPProbe) { p.ProxyURL = proxyURL }
+}
+
+// WithUnacceptableStatusCodes makes the probe fail on the given status codes.
+func WithUnacceptableStatusCodes(codes ...int) Option {
+	return func(p *HTTPProbe) { p.UnacceptableStatusCodes = codes }
+}
+
+// WithValidStatusCodes sets the accepted status codes, classes ("2xx") or
+// ranges ("200-299").
+func WithValidStatusCodes(specs ...string) Option {
+	return func(p *HTTPProbe) { p.ValidStatusCodes = specs }
+}
+
+// WithRedirects sets the redirect policy: "follow", "none" or a maximum
+// number of hops.
+func WithRedirects(policy string) Option {
+	return func(p *HTTPProbe) { p.Redirects = policy }
+}
+
+// WithTimeout sets the request timeout (default 2s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *HTTPProbe) { p.Timeout = timeout }
+}
+
+// WithSkipTLSVerify disables verification of the server certificate.
+func WithSkipTLSVerify(skip bool) Option {
+	return func(p *HTTPProbe) { p.SkipTLSVerify = skip }
+}
+
+// WithAssertions checks the response body and headers after the status code.
+func WithAssertions(a Assertions) Option {
+	return func(p *HTTPProbe) { p.Assertions = a }
+}
+
+// WithTLSCheck sets the certificate expiry window, expected hostname and
+// minimum TLS version.
+func WithTLSCheck(c tlscheck.Config) Option {
+	return func(p *HTTPProbe) { p.TLSCheck = c }
+}
+
+// WithTLS sets the CA bundle, client certificate and other TLS settings.
+func WithTLS(c tlsconfig.Config) Option {
+	return func(p *HTTPProbe) { p.TLS = c }
+}
+
+// WithBasicAuth authenticates with a username and password.
+func WithBasicAuth(auth BasicAuth) Option {
+	return func(p *HTTPProbe) { p.BasicAuth = &auth }
+}
+
+// WithBearerTokenFile sends the token in path, re-read on every probe.
+func WithBearerTokenFile(path string) Option {
+	return func(p *HTTPProbe) { p.BearerTokenFile = path }
+}
+
+// WithOAuth2 authenticates with a token from an OAuth2 client-credentials
+// grant.
+func WithOAuth2(auth OAuth2) Option {
+	return func(p *HTTPProbe) { p.OAuth2 = &auth }
+}
+
+// WithConnection sets the connection mode: ConnectionFresh or
+// ConnectionKeepAlive.
+func WithConnection(mode string) Option {
+	return func(p *HTTPProbe) { p.Connection = mode }
+}
+
+// WithProtocol sets the HTTP protocol; with require a response over another
+// protocol fails the probe.
+func WithProtocol(protocol string, require bool) Option {
+	return func(p *HTTPProbe) {
+		p.Protocol = protocol
+		p.RequireProtocol = require
+	}
+}
+
+// WithIPProtocol restricts resolving and dialing to "ip4" or "ip6".
+func WithIPProtocol(ipProtocol string) Option {
+	return func(p *HTTPProbe) { p.IPProtocol = ipProtocol }
+}
+
+// WithSourceIP binds connections to the given local address.
+func WithSourceIP(ip string) Option {
+	return func(p *HTTPProbe) { p.SourceIP = ip }
+}
+
+// WithRegion sets the region of the probed endpoint.
+func WithRegion(region string) Option {
+	return func(p *HTTPProbe) { p.Region = region }
+}
+
+// NewHTTPProbe creates an HTTPProbe that requests endpoint.
+func NewHTTPProbe(endpoint string, opts ...Option) *HTTPProbe {
+	p := &HTTPProbe{
+		Endpoint: endpoint,
+		Method:   http.MethodGet,
+		Timeout:  2 * time.Second,
+		Region:   "",
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *HTTPProbe) Probe(ctx context.Context) error {
+	err := p.do(ctx)
+	if err != nil {
+		reason := failureReason(err)
+		failures.Inc(ctx, reason)
+		probe.AddDetail(ctx, "Reason", reason)
+	}
+	return err
+}
+
+// failureReason classifies a probe error for prober_http_failures_total.
+func failureReason(err error) string {
+	var authErr *AuthError
+	var statusErr *HTTPStatusError
+	var assertionErr *AssertionError
+	var protocolErr *ProtocolError
+	var extractErr *ExtractError
+	switch {
+	case errors.As(err, &authErr):
+		return "auth"
+	case errors.As(err, &statusErr):
+		return "status"
+	case errors.As(err, &assertionErr):
+		return "assertion"
+	case errors.As(err, &protocolErr):
+		return "protocol"
+	case errors.As(err, &extractErr):
+		return "extract"
+	}
+	return "request"
+}
+
+// newClient builds the client the probe reuses across runs.
+func (p *HTTPProbe) newClient() (*http.Client, error) {
+	transport := &http.Transport{
+		DisableKeepAlives: p.Connection != ConnectionKeepAlive,
+		IdleConnTimeout:   90 * time.Second,
+	}
+	proxy, err := p.proxy()
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	c := p.TLS
+	c.InsecureSkipVerify = c.InsecureSkipVerify || p.SkipTLSVerify
+	p.tlsSettings = c
+	p.tlsConfig = c.ClientConfig()
+	transport.TLSClientConfig = p.tlsConfig
+	checkRedirect, err := checkRedirectFunc(p.Redirects)
+	if err != nil {
+		return nil, err
+	}
+	roundTripper, closeConns, err := p.newTransport(transport)
+	if err != nil {
+		return nil, err
+	}
+	p.closeConns = closeConns
+	return &http.Client{
+		Timeout:       p.Timeout,
+		Transport:     roundTripper,
+		CheckRedirect: checkRedirect,
+	}, nil
+}
+
+// proxy returns the Proxy function of the probe's transports: ProxyURL, or
+// nil for direct connections.
+func (p *HTTPProbe) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if p.ProxyURL == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(p.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(proxy), nil
+}
+
+// Close releases the connections of the probe's client and of its OAuth2
+// token client.
+func (p *HTTPProbe) Close() {
+	if p.closeConns != nil {
+		p.closeConns()
+	}
+	p.tokens.close()
+}
+
+func (p *HTTPProbe) do(ctx context.Context) error {
+	if p.client == nil {
+		client, err := p.newClient()
+		if err != nil {
+			return err
+		}
+		p.client = client
+	}
+	if p.Connection != ConnectionKeepAlive {
+		// HTTP/1 connections are closed by the transport already; this
+		// covers the HTTP/2 and HTTP/3 transports.
+		defer p.client.CloseIdleConnections()
+	}
+
+	endpoint, headers, bodyText := p.Endpoint, p.Headers, p.Body
+	if !p.flowStep {
+		// Flow steps are rendered by the flow, with its variables.
+		var err error
+		if endpoint, headers, bodyText, err = p.render(); err != nil {
+			return err
+		}
+	}
+	var body io.Reader
+	if bodyText != "" {
+		body = strings.NewReader(bodyText)
+	}
+
+	timer := newPhaseTimer()
+	if !p.flowStep {
+		defer timer.report(ctx)
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, timer.trace()), p.Method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if err := p.authenticate(req); err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = p.checkProtocol(ctx, resp)
+	if err == nil && resp.TLS != nil {
+		err = p.TLSCheck.Check(ctx, resp.Request.URL.Host, resp.TLS)
+	}
+	if err == nil {
+		err = p.checkStatus(resp)
+		if method := p.authMethod(); err != nil && method != "" && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			err = &AuthError{Method: method, Err: err}
+		}
+	}
+	if err == nil {
+		err = p.checkBody(resp)
+	}
+	// Read the rest of the body so the transfer phase covers all of it.
+	if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil && err == nil {
+		err = copyErr
+	}
+	timer.bodyDone()
+	return err
+}
+
+// render executes the endpoint, header and body templates.
+func (p *HTTPProbe) render() (string, map[string]string, string, error) {
+	if p.templates == nil {
+		t, err := parseRequestTemplate("endpoint", p.Endpoint, p.Headers, p.Body)
+		if err != nil {
+			return "", nil, "", err
+		}
+		p.templates = t
+	}
+	return p.templates.render(nil)
+}
+
+// checkStatus applies the allow-list, then the deny-list. For compatibility a
+// probe with only a deny-list accepts every code not on it.
+func (p *HTTPProbe) checkStatus(resp *http.Response) error {
+	statusErr := &HTTPStatusError{StatusCode: resp.StatusCode, Method: p.Method, URL: resp.Request.URL.String()}
+	for _, code := range p.UnacceptableStatusCodes {
+		if resp.StatusCode == code {
+			return statusErr
+		}
+	}
+	if len(p.ValidStatusCodes) == 0 && len(p.UnacceptableStatusCodes) > 0 {
+		return nil
+	}
+	if p.validCodes == nil {
+		specs := p.ValidStatusCodes
+		if len(specs) == 0 {
+			specs = []string{"2xx"}
+		}
+		ranges, err := parseStatusCodes(specs)
+		if err != nil {
+			return err
+		}
+		p.validCodes = ranges
+	}
+	if !statusInRanges(resp.StatusCode, p.validCodes) {
+		return statusErr
+	}
+	return nil
+}
+
+// checkBody reads as much of the body as the assertions and onResponse need,
+// then runs them.
+func (p *HTTPProbe) checkBody(resp *http.Response) error {
+	if p.Assertions.empty() && p.onResponse == nil {
+		return nil
+	}
+	if p.assertions == nil {
+		compiled, err := p.Assertions.compile()
+		if err != nil {
+			return err
+		}
+		p.assertions = compiled
+	}
+	var body []byte
+	truncated := false
+	if p.Assertions.needsBody() || p.onResponse != nil {
+		limit := p.Assertions.MaxBodyBytes
+		if limit <= 0 {
+			limit = defaultMaxBodyBytes
+		}
+		var err error
+		body, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(body)) > limit {
+			body, truncated = body[:limit], true
+		}
+	}
+	if err := p.assertions.check(resp.Header, body, truncated); err != nil {
+		return err
+	}
+	if p.onResponse != nil {
+		return p.onResponse(resp, body)
+	}
+	return nil
+}
+
+func (p *HTTPProbe) MetadataString() string {
+	return fmt.Sprintf("Endpoint: %s , Method: %s , Proxy: %s , Region: %s", p.Endpoint, p.Method, p.ProxyURL, p.Region)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evalJSONPath evaluates a JSONPath expression against a document decoded by
+// encoding/json. Only the common subset is supported: a leading $, dotted
+// keys, ['quoted'] keys and [n] array indexes, e.g. $.items[0]['name'].
+// found is false when the path doesn't exist in the document.
+func evalJSONPath(doc interface{}, path string) (value interface{}, found bool, err error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	cur := doc
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+			if cur, ok = obj[s]; !ok {
+				return nil, false, nil
+			}
+		case int:
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+			if s < 0 {
+				s += len(arr)
+			}
+			if s < 0 || s >= len(arr) {
+				return nil, false, nil
+			}
+			cur = arr[s]
+		}
+	}
+	return cur, true, nil
+}
+
+// parseJSONPath splits path into string keys and int indexes.
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("jsonPath %q must start with $", path)
+	}
+	var steps []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("jsonPath %q: empty key", path)
+			}
+			steps = append(steps, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonPath %q: unterminated [", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, inner[1:len(inner)-1])
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("jsonPath %q: unsupported selector [%s]", path, inner)
+			}
+			steps = append(steps, i)
+		default:
+			return nil, fmt.Errorf("jsonPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+	"golang.org/x/net/http2"
+)
+
+var protocolInfo = probe.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "prober_http_protocol_info",
+	Help: "Protocol of the last HTTP probe response, e.g. HTTP/2.0; always 1",
+}, "protocol")
+
+// HTTP protocols. Without a protocol HTTP/2 is negotiated through ALPN on
+// https URLs and HTTP/1.1 is used otherwise.
+const (
+	ProtocolHTTP1 = "http1.1"
+	ProtocolH2    = "h2"
+	ProtocolH2C   = "h2c" // HTTP/2 over cleartext, with prior knowledge
+	ProtocolH3    = "h3"  // HTTP/3 over QUIC
+)
+
+// protoNames are the response Proto values of the protocols.
+var protoNames = map[string]string{
+	ProtocolHTTP1: "HTTP/1.1",
+	ProtocolH2:    "HTTP/2.0",
+	ProtocolH2C:   "HTTP/2.0",
+	ProtocolH3:    "HTTP/3.0",
+}
+
+// ProtocolError reports that the server answered with another protocol than
+// the required one.
+type ProtocolError struct {
+	Want string
+	Got  string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("negotiated %s, want %s", e.Got, e.Want)
+}
+
+// validateProtocol checks the protocol, IP family and source IP settings.
+// endpoint is the rendered endpoint, not its template.
+func validateProtocol(protocol string, require bool, ipProtocol, sourceIP, endpoint, proxyURL string) error {
+	switch protocol {
+	case "", ProtocolHTTP1:
+	case ProtocolH2:
+		if !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("protocol h2 needs an https:// endpoint; use h2c for cleartext HTTP/2")
+		}
+	case ProtocolH2C:
+		if !strings.HasPrefix(endpoint, "http://") {
+			return fmt.Errorf("protocol h2c needs an http:// endpoint")
+		}
+	case ProtocolH3:
+		if !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("protocol h3 needs an https:// endpoint")
+		}
+	default:
+		return fmt.Errorf("invalid protocol %q, want http1.1, h2, h2c or h3", protocol)
+	}
+	if require && protocol == "" {
+		return fmt.Errorf("requireProtocol needs a protocol")
+	}
+	if proxyURL != "" && (protocol == ProtocolH2C || protocol == ProtocolH3) {
+		return fmt.Errorf("protocol %s can't be used with a proxy", protocol)
+	}
+	switch ipProtocol {
+	case "", "auto", "ip4", "ip6":
+	default:
+		return fmt.Errorf("invalid ipProtocol %q, want ip4, ip6 or auto", ipProtocol)
+	}
+	if sourceIP != "" && net.ParseIP(sourceIP) == nil {
+		return fmt.Errorf("invalid sourceIP %q", sourceIP)
+	}
+	return nil
+}
+
+// network restricts a "tcp" or "udp" network to the IP family of the probe.
+func (p *HTTPProbe) network(network string) string {
+	switch p.IPProtocol {
+	case "ip4":
+		return network + "4"
+	case "ip6":
+		return network + "6"
+	}
+	return network
+}
+
+// dialContext dials TCP with the probe's IP family and source IP.
+func (p *HTTPProbe) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: p.Timeout}
+	if p.SourceIP != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(p.SourceIP)}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, p.network(strings.TrimRight(network, "46")), addr)
+	}
+}
+
+// dialTLSContext dials like dial and completes the TLS handshake itself, so
+// the certificate is checked against the dialed host also when that is an IP
+// address, which the transport would not send as SNI.
+func (p *HTTPProbe) dialTLSContext(dial func(ctx context.Context, network, addr string) (net.Conn, error), nextProtos ...string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := p.tlsSettings.ClientConfigFor(tlsconfig.ServerName(addr))
+		cfg.NextProtos = nextProtos
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// newTransport returns the round tripper for the probe's protocol, and a
+// function releasing its resources.
+func (p *HTTPProbe) newTransport(transport *http.Transport) (http.RoundTripper, func(), error) {
+	dial := p.dialContext()
+	switch p.Protocol {
+	case ProtocolHTTP1:
+		transport.DialContext = dial
+		if p.ProxyURL == "" {
+			transport.DialTLSContext = p.dialTLSContext(dial, "http/1.1")
+		}
+		// A non-nil empty map disables HTTP/2.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return transport, transport.CloseIdleConnections, nil
+	case ProtocolH2C:
+		h2c := &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+		}
+		return h2c, h2c.CloseIdleConnections, nil
+	case ProtocolH3:
+		var laddr *net.UDPAddr
+		if p.SourceIP != "" {
+			laddr = &net.UDPAddr{IP: net.ParseIP(p.SourceIP)}
+		}
+		conn, err := net.ListenUDP(p.network("udp"), laddr)
+		if err != nil {
+			return nil, nil, err
+		}
+		qt := &quic.Transport{Conn: conn}
+		h3 := &http3.Transport{
+			TLSClientConfig: p.tlsConfig,
+			Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+				raddr, err := net.ResolveUDPAddr(p.network("udp"), addr)
+				if err != nil {
+					return nil, err
+				}
+				// tlsCfg carries the server name even for an IP address.
+				h3Cfg := p.tlsSettings.ClientConfigFor(tlsCfg.ServerName)
+				h3Cfg.NextProtos = tlsCfg.NextProtos
+				return qt.DialEarly(ctx, raddr, h3Cfg, cfg)
+			},
+		}
+		return h3, func() {
+			h3.Close()
+			qt.Close()
+		}, nil
+	}
+	transport.DialContext = dial
+	// With a proxy the transport upgrades the tunnel with TLSClientConfig.
+	if p.ProxyURL == "" {
+		transport.DialTLSContext = p.dialTLSContext(dial, "h2", "http/1.1")
+	}
+	// A custom dialer and TLS config turn off HTTP/2 unless forced.
+	transport.ForceAttemptHTTP2 = true
+	return transport, transport.CloseIdleConnections, nil
+}
+
+// checkProtocol reports the negotiated protocol and applies RequireProtocol.
+func (p *HTTPProbe) checkProtocol(ctx context.Context, resp *http.Response) error {
+	if !p.flowStep {
+		protocolInfo.Reset(ctx, nil)
+		protocolInfo.Set(ctx, 1, resp.Proto)
+		probe.AddDetail(ctx, "Protocol", resp.Proto)
+	}
+	if !p.RequireProtocol {
+		return nil
+	}
+	if want := protoNames[p.Protocol]; resp.Proto != want {
+		return &ProtocolError{Want: want, Got: resp.Proto}
+	}
+	return nil
+}
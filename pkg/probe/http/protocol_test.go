@@ -0,0 +1,27 @@
+package http
+
+import "testing"
+
+func TestValidateProtocol(t *testing.T) {
+	tests := []struct {
+		protocol, endpoint, proxyURL string
+		wantErr                      bool
+	}{
+		{protocol: "", endpoint: "http://example.com"},
+		{protocol: ProtocolHTTP1, endpoint: "http://example.com"},
+		{protocol: ProtocolH2, endpoint: "https://example.com"},
+		{protocol: ProtocolH2, endpoint: "http://example.com", wantErr: true},
+		{protocol: ProtocolH2C, endpoint: "http://example.com"},
+		{protocol: ProtocolH2C, endpoint: "https://example.com", wantErr: true},
+		{protocol: ProtocolH2C, endpoint: "http://example.com", proxyURL: "http://proxy:3128", wantErr: true},
+		{protocol: ProtocolH3, endpoint: "https://example.com"},
+		{protocol: ProtocolH3, endpoint: "http://example.com", wantErr: true},
+		{protocol: "spdy", endpoint: "https://example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateProtocol(tt.protocol, false, "", "", tt.endpoint, tt.proxyURL)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateProtocol(%q, %q, proxy %q) error = %v, wantErr %v", tt.protocol, tt.endpoint, tt.proxyURL, err, tt.wantErr)
+		}
+	}
+}
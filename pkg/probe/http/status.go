@@ -0,0 +1,90 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// statusRange is an inclusive range of status codes.
+type statusRange struct {
+	lo, hi int
+}
+
+// parseStatusCodes parses status code specs: a code ("204"), a class ("2xx")
+// or an inclusive range ("200-299").
+func parseStatusCodes(specs []string) ([]statusRange, error) {
+	ranges := make([]statusRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		var r statusRange
+		switch {
+		case len(spec) == 3 && strings.HasSuffix(strings.ToLower(spec), "xx") && spec[0] >= '1' && spec[0] <= '5':
+			r.lo = int(spec[0]-'0') * 100
+			r.hi = r.lo + 99
+		case strings.Contains(spec, "-"):
+			lo, hi, _ := strings.Cut(spec, "-")
+			var err1, err2 error
+			r.lo, err1 = strconv.Atoi(strings.TrimSpace(lo))
+			r.hi, err2 = strconv.Atoi(strings.TrimSpace(hi))
+			if err1 != nil || err2 != nil || r.lo > r.hi {
+				return nil, fmt.Errorf("invalid status code range %q", spec)
+			}
+		default:
+			code, err := strconv.Atoi(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code %q", spec)
+			}
+			r.lo, r.hi = code, code
+		}
+		if r.lo < 100 || r.hi > 599 {
+			return nil, fmt.Errorf("status code %q out of range 100-599", spec)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func statusInRanges(code int, ranges []statusRange) bool {
+	for _, r := range ranges {
+		if code >= r.lo && code <= r.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRedirectFunc returns the http.Client CheckRedirect for a redirect
+// policy: "follow" (the default, up to 10 hops), "none" to return the
+// redirect response itself, or a maximum number of hops.
+func checkRedirectFunc(policy string) (func(*http.Request, []*http.Request) error, error) {
+	switch policy {
+	case "", "follow":
+		return nil, nil
+	case "none":
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}, nil
+	}
+	max, err := strconv.Atoi(policy)
+	if err != nil || max < 1 {
+		return nil, fmt.Errorf("invalid redirects %q: use follow, none or a number of hops >= 1", policy)
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		return nil
+	}, nil
+}
+
+type HTTPStatusError struct {
+	StatusCode int
+	Method     string
+	URL        string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d (%s) for %s %s", e.StatusCode, http.StatusText(e.StatusCode), e.Method, e.URL)
+}
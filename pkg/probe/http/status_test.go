@@ -0,0 +1,92 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	tests := []struct {
+		specs   []string
+		want    []statusRange
+		wantErr bool
+	}{
+		{specs: nil, want: []statusRange{}},
+		{specs: []string{"204"}, want: []statusRange{{204, 204}}},
+		{specs: []string{"2xx", "3XX"}, want: []statusRange{{200, 299}, {300, 399}}},
+		{specs: []string{"200-299", " 404 "}, want: []statusRange{{200, 299}, {404, 404}}},
+		{specs: []string{"200 - 204"}, want: []statusRange{{200, 204}}},
+		{specs: []string{"6xx"}, wantErr: true},
+		{specs: []string{"ok"}, wantErr: true},
+		{specs: []string{"299-200"}, wantErr: true},
+		{specs: []string{"200-"}, wantErr: true},
+		{specs: []string{"99"}, wantErr: true},
+		{specs: []string{"500-600"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseStatusCodes(tt.specs)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStatusCodes(%q) error = %v, wantErr %v", tt.specs, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStatusCodes(%q) = %v, want %v", tt.specs, got, tt.want)
+		}
+	}
+}
+
+func TestStatusInRanges(t *testing.T) {
+	ranges, err := parseStatusCodes([]string{"2xx", "301", "400-403"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for code, want := range map[int]bool{200: true, 299: true, 300: false, 301: true, 399: false, 403: true, 404: false, 500: false} {
+		if got := statusInRanges(code, ranges); got != want {
+			t.Errorf("statusInRanges(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestCheckRedirectFunc(t *testing.T) {
+	via := func(n int) []*http.Request { return make([]*http.Request, n) }
+	tests := []struct {
+		policy     string
+		hops       int
+		wantNil    bool
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{policy: "", wantNil: true},
+		{policy: "follow", wantNil: true},
+		{policy: "none", hops: 1, wantErr: http.ErrUseLastResponse},
+		{policy: "2", hops: 2},
+		{policy: "2", hops: 3, wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		f, err := checkRedirectFunc(tt.policy)
+		if err != nil {
+			t.Errorf("checkRedirectFunc(%q): %v", tt.policy, err)
+			continue
+		}
+		if (f == nil) != tt.wantNil {
+			t.Errorf("checkRedirectFunc(%q) nil = %v, want %v", tt.policy, f == nil, tt.wantNil)
+			continue
+		}
+		if f == nil {
+			continue
+		}
+		err = f(nil, via(tt.hops))
+		switch {
+		case tt.wantErr != nil && err != tt.wantErr:
+			t.Errorf("policy %q after %d hops: error = %v, want %v", tt.policy, tt.hops, err, tt.wantErr)
+		case tt.wantErr == nil && (err != nil) != tt.wantAnyErr:
+			t.Errorf("policy %q after %d hops: error = %v, want error %v", tt.policy, tt.hops, err, tt.wantAnyErr)
+		}
+	}
+	for _, policy := range []string{"always", "0", "-1"} {
+		if _, err := checkRedirectFunc(policy); err == nil {
+			t.Errorf("checkRedirectFunc(%q) accepted an invalid policy", policy)
+		}
+	}
+}
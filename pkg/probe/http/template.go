@@ -0,0 +1,159 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions available in request templates:
+//
+//	now             current time as RFC 3339 in UTC; now "unix" and
+//	                now "unixMilli" give Unix time, any other argument is a
+//	                Go time layout
+//	uuid            random version 4 UUID
+//	randString N    N random letters and digits
+//	env NAME        environment variable NAME, which must be set
+//	hmacSHA256 K M  hex HMAC-SHA256 of message M with key K
+//	base64 S        standard base64 encoding of S
+var templateFuncs = template.FuncMap{
+	"now":        templateNow,
+	"uuid":       templateUUID,
+	"randString": templateRandString,
+	"env":        templateEnv,
+	"hmacSHA256": templateHMACSHA256,
+	"base64":     templateBase64,
+}
+
+func templateNow(layout ...string) (string, error) {
+	now := time.Now().UTC()
+	if len(layout) > 1 {
+		return "", fmt.Errorf("now takes at most one layout")
+	}
+	if len(layout) == 0 {
+		return now.Format(time.RFC3339), nil
+	}
+	switch layout[0] {
+	case "unix":
+		return fmt.Sprint(now.Unix()), nil
+	case "unixMilli":
+		return fmt.Sprint(now.UnixMilli()), nil
+	}
+	return now.Format(layout[0]), nil
+}
+
+func templateUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+const randAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func templateRandString(n int) (string, error) {
+	if n < 0 || n > 4096 {
+		return "", fmt.Errorf("randString length %d out of range 0-4096", n)
+	}
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(randAlphabet)))
+	for i := range b {
+		j, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = randAlphabet[j.Int64()]
+	}
+	return string(b), nil
+}
+
+func templateEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func templateHMACSHA256(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func templateBase64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// requestTemplate is the URL, header values and body of a request, rendered
+// on every run.
+type requestTemplate struct {
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// parseRequestTemplate parses the templates; urlName names the URL field in
+// errors.
+func parseRequestTemplate(urlName, url string, headers map[string]string, body string) (*requestTemplate, error) {
+	r := &requestTemplate{headers: make(map[string]*template.Template, len(headers))}
+	var err error
+	if r.url, err = parseTemplate(urlName, url); err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		if r.headers[k], err = parseTemplate("headers["+k+"]", v); err != nil {
+			return nil, err
+		}
+	}
+	if r.body, err = parseTemplate("body", body); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func execute(t *template.Template, vars map[string]string) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// render returns the URL, headers and body for vars.
+func (r *requestTemplate) render(vars map[string]string) (string, map[string]string, string, error) {
+	url, err := execute(r.url, vars)
+	if err != nil {
+		return "", nil, "", err
+	}
+	headers := make(map[string]string, len(r.headers))
+	for k, t := range r.headers {
+		if headers[k], err = execute(t, vars); err != nil {
+			return "", nil, "", err
+		}
+	}
+	body, err := execute(r.body, vars)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return url, headers, body, nil
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+var phaseDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "prober_http_phase_duration_seconds",
+	Help:    "Duration of each phase of an HTTP probe request",
+	Buckets: prometheus.DefBuckets,
+}, "phase")
+
+var connections = probe.NewCounterVec(prometheus.CounterOpts{
+	Name: "prober_http_connections_total",
+	Help: "Connections used by HTTP probe requests, by whether they were new or reused",
+}, "connection")
+
+// phases are reported in this order. processing runs from the request being
+// written to the first response byte; transfer from there to the end of the
+// body.
+var phases = []string{"resolve", "connect", "tls", "processing", "transfer"}
+
+// phaseTimer collects the phase durations of one probe run through
+// httptrace. Durations of the same phase add up across redirects; phases a
+// request skips, like connect on a reused connection, are not reported.
+type phaseTimer struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	wrote     time.Time
+	firstByte time.Time
+	gotConn   bool
+	reused    bool
+	remote    string
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{durations: make(map[string]time.Duration)}
+}
+
+func (t *phaseTimer) add(phase string, since time.Time) {
+	if since.IsZero() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[phase] += time.Since(since)
+}
+
+func (t *phaseTimer) set(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*field = time.Now()
+}
+
+func (t *phaseTimer) get(field *time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *field
+}
+
+func (t *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.add("resolve", t.get(&t.dnsStart)) },
+		ConnectStart: func(string, string) {
+			t.set(&t.connStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.add("connect", t.get(&t.connStart))
+		},
+		TLSHandshakeStart: func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.add("tls", t.get(&t.tlsStart))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = true
+			t.reused = info.Reused
+			t.remote = info.Conn.RemoteAddr().String()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { t.set(&t.wrote) },
+		GotFirstResponseByte: func() {
+			t.add("processing", t.get(&t.wrote))
+			t.set(&t.firstByte)
+		},
+	}
+}
+
+// bodyDone ends the transfer phase of the final response.
+func (t *phaseTimer) bodyDone() {
+	t.add("transfer", t.get(&t.firstByte))
+}
+
+// report exports the phases as metrics and adds them and whether the
+// connection was reused to the run details.
+func (t *phaseTimer) report(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.gotConn {
+		connection := "new"
+		if t.reused {
+			connection = "reused"
+		}
+		connections.Inc(ctx, connection)
+		probe.AddDetail(ctx, "Connection", connection+" to "+t.remote)
+	}
+	var parts []string
+	for _, phase := range phases {
+		d, ok := t.durations[phase]
+		if !ok {
+			continue
+		}
+		phaseDuration.Observe(ctx, d.Seconds(), phase)
+		parts = append(parts, fmt.Sprintf("%s=%s", phase, d.Round(time.Microsecond)))
+	}
+	if len(parts) > 0 {
+		probe.AddDetail(ctx, "Phases", strings.Join(parts, " "))
+	}
+}
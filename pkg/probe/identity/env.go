@@ -0,0 +1,20 @@
+package identity
+
+import (
+	"context"
+	"os"
+)
+
+// Env reads the identity from the SOURCE_REGION, NODE_NAME and NODE_IP
+// environment variables.
+type Env struct{}
+
+func (Env) Name() string { return "env" }
+
+func (Env) Resolve(ctx context.Context) (Identity, error) {
+	return Identity{
+		Region:   os.Getenv("SOURCE_REGION"),
+		NodeName: os.Getenv("NODE_NAME"),
+		NodeIP:   os.Getenv("NODE_IP"),
+	}, nil
+}
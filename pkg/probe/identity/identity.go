@@ -0,0 +1,77 @@
+// Package identity resolves where the prober itself runs: its source region,
+// node name and node IP. Providers are tried in order and each fills in the
+// fields the earlier ones left empty.
+package identity
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Identity describes the node the prober runs on.
+type Identity struct {
+	Region   string
+	NodeName string
+	NodeIP   string
+	// Provider lists the providers that contributed to the identity, e.g. "aws,env".
+	Provider string
+}
+
+// Default is the identity used for fields no provider could resolve.
+var Default = Identity{Region: "local", NodeName: "local", NodeIP: "unknown", Provider: "default"}
+
+// Provider resolves some or all fields of an Identity.
+type Provider interface {
+	Name() string
+	Resolve(ctx context.Context) (Identity, error)
+}
+
+// Resolve asks each provider in order, giving each at most timeout, and
+// merges their answers: a field is taken from the first provider that sets it.
+// Fields nobody sets fall back to Default. Provider errors are logged and the
+// next provider is tried.
+func Resolve(ctx context.Context, timeout time.Duration, providers ...Provider) Identity {
+	var id Identity
+	var used []string
+	for _, p := range providers {
+		if id.Region != "" && id.NodeName != "" && id.NodeIP != "" {
+			break
+		}
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		got, err := p.Resolve(pctx)
+		cancel()
+		if err != nil {
+			log.Printf("[Identity] provider %s failed: %v", p.Name(), err)
+			continue
+		}
+		contributed := false
+		if id.Region == "" && got.Region != "" {
+			id.Region, contributed = got.Region, true
+		}
+		if id.NodeName == "" && got.NodeName != "" {
+			id.NodeName, contributed = got.NodeName, true
+		}
+		if id.NodeIP == "" && got.NodeIP != "" {
+			id.NodeIP, contributed = got.NodeIP, true
+		}
+		if contributed {
+			used = append(used, p.Name())
+		}
+	}
+	if id.Region == "" {
+		id.Region = Default.Region
+	}
+	if id.NodeName == "" {
+		id.NodeName = Default.NodeName
+	}
+	if id.NodeIP == "" {
+		id.NodeIP = Default.NodeIP
+	}
+	id.Provider = strings.Join(used, ",")
+	if id.Provider == "" {
+		id.Provider = Default.Provider
+	}
+	return id
+}
@@ -0,0 +1,227 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAWSResolveUsesIMDSv2Token(t *testing.T) {
+	const token = "test-token"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			if r.Method != http.MethodPut {
+				t.Errorf("token request method = %s, want PUT", r.Method)
+			}
+			if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				t.Error("token request without TTL header")
+			}
+			w.Write([]byte(token))
+			return
+		}
+		if got := r.Header.Get("X-aws-ec2-metadata-token"); got != token {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/placement/region":
+			w.Write([]byte("eu-west-1\n"))
+		case "/latest/meta-data/local-hostname":
+			w.Write([]byte("ip-10-0-0-1.eu-west-1.compute.internal"))
+		case "/latest/meta-data/local-ipv4":
+			w.Write([]byte("10.0.0.1"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := &AWS{Endpoint: srv.URL, Client: srv.Client()}
+	got, err := p.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := Identity{Region: "eu-west-1", NodeName: "ip-10-0-0-1.eu-west-1.compute.internal", NodeIP: "10.0.0.1"}
+	if got != want {
+		t.Errorf("Resolve = %+v, want %+v", got, want)
+	}
+}
+
+func TestAWSResolveTokenFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	p := &AWS{Endpoint: srv.URL, Client: srv.Client()}
+	if _, err := p.Resolve(context.Background()); err == nil {
+		t.Fatal("Resolve succeeded without a token")
+	}
+}
+
+func TestGCEResolveDerivesRegionFromZone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/zone":
+			w.Write([]byte("projects/123456/zones/us-central1-a"))
+		case "/computeMetadata/v1/instance/name":
+			w.Write([]byte("gke-node-1"))
+		case "/computeMetadata/v1/instance/network-interfaces/0/ip":
+			w.Write([]byte("10.128.0.2"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := &GCE{Endpoint: srv.URL, Client: srv.Client()}
+	got, err := p.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := Identity{Region: "us-central1", NodeName: "gke-node-1", NodeIP: "10.128.0.2"}
+	if got != want {
+		t.Errorf("Resolve = %+v, want %+v", got, want)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKubernetesResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Identity
+	}{
+		{
+			name:  "plain files",
+			files: map[string]string{"region": "eu-west-1\n", "nodeName": "node-a", "nodeIP": "10.0.0.5"},
+			want:  Identity{Region: "eu-west-1", NodeName: "node-a", NodeIP: "10.0.0.5"},
+		},
+		{
+			name: "region from labels",
+			files: map[string]string{
+				"nodeName": "node-b",
+				"labels":   "app=\"prober\"\ntopology.kubernetes.io/region=\"us-east-1\"\n",
+			},
+			want: Identity{Region: "us-east-1", NodeName: "node-b"},
+		},
+		{
+			name: "region from annotations",
+			files: map[string]string{
+				"labels":      "app=\"prober\"\n",
+				"annotations": "topology.kubernetes.io/region=\"ap-south-1\"\n",
+			},
+			want: Identity{Region: "ap-south-1"},
+		},
+		{
+			name:  "region file wins over labels",
+			files: map[string]string{"region": "eu-west-1", "labels": "topology.kubernetes.io/region=\"us-east-1\"\n"},
+			want:  Identity{Region: "eu-west-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			got, err := (&Kubernetes{Dir: dir}).Resolve(context.Background())
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesResolveEmptyDir(t *testing.T) {
+	if _, err := (&Kubernetes{Dir: t.TempDir()}).Resolve(context.Background()); err == nil {
+		t.Error("Resolve succeeded on an empty directory")
+	}
+	if _, err := (&Kubernetes{Dir: filepath.Join(t.TempDir(), "missing")}).Resolve(context.Background()); err == nil {
+		t.Error("Resolve succeeded on a missing directory")
+	}
+}
+
+type staticProvider struct {
+	name string
+	id   Identity
+	err  error
+}
+
+func (p staticProvider) Name() string { return p.name }
+
+func (p staticProvider) Resolve(ctx context.Context) (Identity, error) {
+	return p.id, p.err
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      Identity
+	}{
+		{
+			name:      "no providers",
+			providers: nil,
+			want:      Default,
+		},
+		{
+			name: "first provider wins per field",
+			providers: []Provider{
+				staticProvider{name: "env", id: Identity{Region: "override"}},
+				staticProvider{name: "aws", id: Identity{Region: "eu-west-1", NodeName: "node-a", NodeIP: "10.0.0.1"}},
+			},
+			want: Identity{Region: "override", NodeName: "node-a", NodeIP: "10.0.0.1", Provider: "env,aws"},
+		},
+		{
+			name: "failing provider is skipped",
+			providers: []Provider{
+				staticProvider{name: "aws", err: errors.New("unreachable")},
+				staticProvider{name: "kubernetes", id: Identity{NodeName: "node-b"}},
+			},
+			want: Identity{Region: Default.Region, NodeName: "node-b", NodeIP: Default.NodeIP, Provider: "kubernetes"},
+		},
+		{
+			name: "providers after a complete identity are not consulted",
+			providers: []Provider{
+				staticProvider{name: "env", id: Identity{Region: "r", NodeName: "n", NodeIP: "i"}},
+				staticProvider{name: "aws", id: Identity{Region: "other"}},
+			},
+			want: Identity{Region: "r", NodeName: "n", NodeIP: "i", Provider: "env"},
+		},
+		{
+			name: "provider without new fields is not listed",
+			providers: []Provider{
+				staticProvider{name: "env", id: Identity{Region: "r"}},
+				staticProvider{name: "gce", id: Identity{Region: "other"}},
+			},
+			want: Identity{Region: "r", NodeName: Default.NodeName, NodeIP: Default.NodeIP, Provider: "env"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resolve(context.Background(), time.Second, tt.providers...)
+			if got != tt.want {
+				t.Errorf("Resolve = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
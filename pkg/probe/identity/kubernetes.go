@@ -0,0 +1,82 @@
+package identity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultKubernetesDir is where the downward API volume is usually mounted.
+const DefaultKubernetesDir = "/etc/podinfo"
+
+// regionKey is the well-known topology label/annotation holding the region.
+const regionKey = "topology.kubernetes.io/region"
+
+// Kubernetes reads the identity from a downward API volume. The files
+// region, nodeName and nodeIP are used when present; otherwise the region is
+// looked up under topology.kubernetes.io/region in the labels and annotations
+// files.
+type Kubernetes struct {
+	Dir string // defaults to DefaultKubernetesDir
+}
+
+func (p *Kubernetes) Name() string { return "kubernetes" }
+
+func (p *Kubernetes) Resolve(ctx context.Context) (Identity, error) {
+	dir := p.Dir
+	if dir == "" {
+		dir = DefaultKubernetesDir
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return Identity{}, err
+	}
+	id := Identity{
+		Region:   readFile(filepath.Join(dir, "region")),
+		NodeName: readFile(filepath.Join(dir, "nodeName")),
+		NodeIP:   readFile(filepath.Join(dir, "nodeIP")),
+	}
+	for _, name := range []string{"labels", "annotations"} {
+		if id.Region != "" {
+			break
+		}
+		id.Region = lookupDownwardKey(filepath.Join(dir, name), regionKey)
+	}
+	if id == (Identity{}) {
+		return Identity{}, errors.New("no identity files found in " + dir)
+	}
+	return id, nil
+}
+
+func readFile(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// lookupDownwardKey finds key in a downward API labels/annotations file,
+// which holds one key="quoted value" pair per line.
+func lookupDownwardKey(path, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), "=")
+		if !ok || k != key {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(v); err == nil {
+			return unquoted
+		}
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultAWSEndpoint is the EC2 instance metadata service.
+const DefaultAWSEndpoint = "http://169.254.169.254"
+
+// DefaultGCEEndpoint is the GCE metadata server.
+const DefaultGCEEndpoint = "http://metadata.google.internal"
+
+// AWS reads the identity from the EC2 instance metadata service using IMDSv2
+// session tokens.
+type AWS struct {
+	Endpoint string // defaults to DefaultAWSEndpoint
+	Client   *http.Client
+}
+
+func (p *AWS) Name() string { return "aws" }
+
+func (p *AWS) Resolve(ctx context.Context) (Identity, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultAWSEndpoint
+	}
+	client := clientOrDefault(p.Client)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := fetch(client, req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("imdsv2 token: %w", err)
+	}
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/"+path, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return fetch(client, req)
+	}
+	var id Identity
+	if id.Region, err = get("placement/region"); err != nil {
+		return Identity{}, err
+	}
+	if id.NodeName, err = get("local-hostname"); err != nil {
+		return Identity{}, err
+	}
+	if id.NodeIP, err = get("local-ipv4"); err != nil {
+		return Identity{}, err
+	}
+	return id, nil
+}
+
+// GCE reads the identity from the GCE metadata server. The region is derived
+// from the instance zone.
+type GCE struct {
+	Endpoint string // defaults to DefaultGCEEndpoint
+	Client   *http.Client
+}
+
+func (p *GCE) Name() string { return "gce" }
+
+func (p *GCE) Resolve(ctx context.Context) (Identity, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultGCEEndpoint
+	}
+	client := clientOrDefault(p.Client)
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/computeMetadata/v1/instance/"+path, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		return fetch(client, req)
+	}
+	zone, err := get("zone")
+	if err != nil {
+		return Identity{}, err
+	}
+	var id Identity
+	// zone looks like projects/123456/zones/us-central1-a
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		id.Region = zone[:i]
+	}
+	if id.NodeName, err = get("name"); err != nil {
+		return Identity{}, err
+	}
+	if id.NodeIP, err = get("network-interfaces/0/ip"); err != nil {
+		return Identity{}, err
+	}
+	return id, nil
+}
+
+func clientOrDefault(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	// Metadata services must not be reached through a proxy.
+	return &http.Client{Transport: &http.Transport{Proxy: nil}}
+}
+
+func fetch(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type KafkaCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Brokers           []string `yaml:"brokers"`
+	Topic             string   `yaml:"topic"`
+	// TLS is not supported yet; Validate rejects an enabled tls block
+	// rather than connect in plaintext once the probes are implemented.
+	TLS tlsconfig.Config `yaml:"tls"`
+}
+
+func (c KafkaCluster) Validate() error {
+	if c.TLS.Enabled {
+		return fmt.Errorf("tls: not supported for Kafka clusters yet")
+	}
+	return nil
+}
+
+func init() {
+	probe.Register("kafka", newProbers)
+}
+
+// newProbers returns no probers: the Kafka probes are placeholders, and
+// running them would report every Kafka cluster in the metrics and the
+// matrix without checking anything.
+func newProbers(cluster KafkaCluster) ([]probe.Prober, error) {
+	log.Printf("[Kafka] cluster %s is not probed: Kafka probes are not implemented yet", cluster.Name)
+	return nil, nil
+}
@@ -0,0 +1,90 @@
+// Package kafka will probe Kafka topics; the probes are placeholders for now.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errNotImplemented is returned by the placeholder probes so that a Kafka
+// cluster is reported as failing rather than healthy until real checks exist.
+var errNotImplemented = errors.New("kafka probe not implemented")
+
+type options struct {
+	region string
+}
+
+// Option configures the Kafka probes.
+type Option func(*options)
+
+// WithRegion sets the region of the probed brokers.
+func WithRegion(region string) Option {
+	return func(o *options) { o.region = region }
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewReadProbe creates a ReadProbe for topic
+func NewReadProbe(brokers []string, topic string, opts ...Option) *ReadProbe {
+	o := newOptions(opts)
+	return &ReadProbe{
+		Region:  o.region,
+		Brokers: brokers,
+		Topic:   topic,
+	}
+}
+
+// NewWriteProbe creates a WriteProbe for topic
+func NewWriteProbe(brokers []string, topic string, opts ...Option) *WriteProbe {
+	o := newOptions(opts)
+	return &WriteProbe{
+		Region:  o.region,
+		Brokers: brokers,
+		Topic:   topic,
+	}
+}
+
+type ReadProbe struct {
+	Region  string
+	Brokers []string
+	Topic   string
+}
+
+func (p *ReadProbe) Probe(ctx context.Context) error {
+	// TODO: Implement Kafka read health check
+	return errNotImplemented
+}
+
+func (p *ReadProbe) MetadataString() string {
+	return fmt.Sprintf("Brokers: %v , Topic: %s , Region: %s", p.Brokers, p.Topic, p.Region)
+}
+
+func (p *ReadProbe) Operation() string {
+	return "read"
+}
+
+type WriteProbe struct {
+	Region  string
+	Brokers []string
+	Topic   string
+}
+
+func (p *WriteProbe) Probe(ctx context.Context) error {
+	// TODO: Implement Kafka write health check
+	return errNotImplemented
+}
+
+func (p *WriteProbe) MetadataString() string {
+	return fmt.Sprintf("Brokers: %v , Topic: %s , Region: %s", p.Brokers, p.Topic, p.Region)
+}
+
+func (p *WriteProbe) Operation() string {
+	return "write"
+}
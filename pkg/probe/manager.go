@@ -0,0 +1,133 @@
+package probe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+type probeKey struct {
+	Kind string
+	Name string
+}
+
+// ProbeManager runs the probes of a Config and restarts only the clusters
+// whose config changed when a new Config is applied.
+type ProbeManager struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	probes   map[probeKey]context.CancelFunc
+	configs  map[probeKey][32]byte // hash of config for change detection
+	onResult func(Result)
+}
+
+// ManagerOption configures a ProbeManager.
+type ManagerOption func(*ProbeManager)
+
+// WithResultHandler sets a function called with the result of every probe run,
+// instead of logging it. It is called from the probe goroutines and must not block.
+func WithResultHandler(handler func(Result)) ManagerOption {
+	return func(pm *ProbeManager) { pm.onResult = handler }
+}
+
+func NewProbeManager(ctx context.Context, opts ...ManagerOption) *ProbeManager {
+	c, cancel := context.WithCancel(ctx)
+	pm := &ProbeManager{
+		ctx:      c,
+		cancel:   cancel,
+		probes:   make(map[probeKey]context.CancelFunc),
+		configs:  make(map[probeKey][32]byte),
+		onResult: logResult,
+	}
+	for _, opt := range opts {
+		opt(pm)
+	}
+	return pm
+}
+
+func (pm *ProbeManager) Stop() {
+	pm.cancel()
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for _, cancel := range pm.probes {
+		cancel()
+	}
+	pm.probes = make(map[probeKey]context.CancelFunc)
+	pm.configs = make(map[probeKey][32]byte)
+}
+
+// LaunchOrUpdateProbes validates cfg, then launches or updates probes for all
+// clusters in the config. An invalid config leaves the running probes untouched.
+func (pm *ProbeManager) LaunchOrUpdateProbes(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	resolveIdentity(pm.ctx, cfg.Identity)
+	regionMatrix.configure(cfg.Matrix)
+
+	// Custom label keys may have changed; counters must be re-created before
+	// any probe reports with the new label set.
+	ConfigureLabels(cfg.LabelKeys())
+
+	// Track which clusters are still present after reload
+	activeClusters := make(map[probeKey]struct{})
+
+	for _, pc := range cfg.Probes {
+		base := pc.Cluster.Base()
+		key := probeKey{Kind: pc.Kind, Name: base.Name}
+		labels := cfg.ClusterLabels(base.Labels)
+		interval := cfg.Interval(pc)
+		configBytes, _ := json.Marshal([]interface{}{pc.Cluster, labels, interval})
+		configHash := sha256.Sum256(configBytes)
+		activeClusters[key] = struct{}{}
+
+		// If config is unchanged, do nothing
+		if prevHash, ok := pm.configs[key]; ok && prevHash == configHash {
+			continue
+		}
+
+		k, ok := lookupKind(pc.Kind)
+		if !ok {
+			log.Printf("[ProbeManager] Unknown kind=%s for cluster=%s", pc.Kind, base.Name)
+			continue
+		}
+		probers, err := k.build(pc.Cluster)
+		if err != nil {
+			// Keep the previous probes (if any) running; the build is retried on the next reload.
+			log.Printf("[ProbeManager] Could not create probes for kind=%s, cluster=%s: %v", pc.Kind, base.Name, err)
+			continue
+		}
+
+		// If probe is already running, stop it
+		if cancel, ok := pm.probes[key]; ok {
+			log.Printf("[ProbeManager] Restarting probe for kind=%s, cluster=%s due to config change", pc.Kind, base.Name)
+			cancel()
+		} else {
+			log.Printf("[ProbeManager] Starting probe for kind=%s, cluster=%s", pc.Kind, base.Name)
+		}
+
+		// Start new probe goroutine
+		ctx, cancel := context.WithCancel(pm.ctx)
+		pm.probes[key] = cancel
+		pm.configs[key] = configHash
+
+		go runCluster(ctx, pc.Kind, base, labels, interval, probers, pm.onResult)
+	}
+
+	// --- Remove probes for clusters that no longer exist ---
+	for key := range pm.probes {
+		if _, stillActive := activeClusters[key]; !stillActive {
+			log.Printf("[ProbeManager] Stopping probe for kind=%s, cluster=%s due to config deletion", key.Kind, key.Name)
+			pm.probes[key]()
+			delete(pm.probes, key)
+			delete(pm.configs, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MatrixConfig configures the region-to-region matrix served on /matrix.
+type MatrixConfig struct {
+	// Window is how far back results are kept (default 5m).
+	Window DurationString `yaml:"window"`
+	// Peers are the /matrix URLs of other prober instances whose local
+	// results are merged into the matrix, e.g. http://prober-eu:2112/matrix.
+	Peers []string `yaml:"peers"`
+}
+
+type matrixKey struct {
+	Source      string
+	Destination string
+	Kind        string
+}
+
+type matrixSample struct {
+	at       time.Time
+	ok       bool
+	duration time.Duration
+}
+
+// resultMatrix keeps recent probe results per source region, destination
+// region and kind. Samples older than the window are dropped as new ones
+// arrive, so a cell holds every run of the window and its size follows the
+// number of probers and their interval.
+type resultMatrix struct {
+	mu     sync.Mutex
+	window time.Duration
+	peers  []string
+	cells  map[matrixKey][]matrixSample
+}
+
+var regionMatrix = &resultMatrix{
+	window: 5 * time.Minute,
+	cells:  make(map[matrixKey][]matrixSample),
+}
+
+func (m *resultMatrix) configure(cfg MatrixConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.window = cfg.Window.ToDuration(5 * time.Minute)
+	m.peers = append([]string{}, cfg.Peers...)
+}
+
+func (m *resultMatrix) record(r Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := matrixKey{Source: r.SourceRegion, Destination: r.DestinationRegion, Kind: r.Kind}
+	now := time.Now()
+	samples := append(m.cells[key], matrixSample{at: now, ok: r.Err == nil, duration: r.Duration})
+	m.cells[key] = samples[firstInWindow(samples, now.Add(-m.window)):]
+}
+
+// MatrixCell summarises the results from one source region to one
+// destination region for one probe kind.
+type MatrixCell struct {
+	Source      string  `json:"source"`
+	Destination string  `json:"destination"`
+	Kind        string  `json:"kind"`
+	Samples     int     `json:"samples"`
+	SuccessRate float64 `json:"successRate"`
+	// Latency percentiles of the successful runs, in milliseconds.
+	P50Ms float64 `json:"p50Ms"`
+	P99Ms float64 `json:"p99Ms"`
+}
+
+// Matrix is the JSON document served on /matrix.
+type Matrix struct {
+	GeneratedAt  time.Time         `json:"generatedAt"`
+	Window       string            `json:"window"`
+	Sources      []string          `json:"sources"`
+	Destinations []string          `json:"destinations"`
+	Kinds        []string          `json:"kinds"`
+	Cells        []MatrixCell      `json:"cells"`
+	PeerErrors   map[string]string `json:"peerErrors,omitempty"`
+}
+
+// local summarises the results recorded by this instance within the window.
+func (m *resultMatrix) local() []MatrixCell {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := time.Now().Add(-m.window)
+	var cells []MatrixCell
+	for key, samples := range m.cells {
+		samples = samples[firstInWindow(samples, cutoff):]
+		if len(samples) == 0 {
+			delete(m.cells, key)
+			continue
+		}
+		m.cells[key] = samples
+
+		var ok int
+		var latencies []time.Duration
+		for _, s := range samples {
+			if s.ok {
+				ok++
+				latencies = append(latencies, s.duration)
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		cells = append(cells, MatrixCell{
+			Source:      key.Source,
+			Destination: key.Destination,
+			Kind:        key.Kind,
+			Samples:     len(samples),
+			SuccessRate: float64(ok) / float64(len(samples)),
+			P50Ms:       percentileMs(latencies, 0.50),
+			P99Ms:       percentileMs(latencies, 0.99),
+		})
+	}
+	return cells
+}
+
+// firstInWindow returns the index of the first sample taken at or after
+// cutoff; samples are in time order.
+func firstInWindow(samples []matrixSample, cutoff time.Time) int {
+	return sort.Search(len(samples), func(i int) bool { return !samples[i].at.Before(cutoff) })
+}
+
+func percentileMs(sorted []time.Duration, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return float64(sorted[i]) / float64(time.Millisecond)
+}
+
+// build returns the matrix of local results, merged with the local results of
+// the peers when includePeers is set.
+func (m *resultMatrix) build(ctx context.Context, includePeers bool) Matrix {
+	m.mu.Lock()
+	window := m.window
+	peers := append([]string{}, m.peers...)
+	m.mu.Unlock()
+
+	cells := m.local()
+	var peerErrors map[string]string
+	if includePeers && len(peers) > 0 {
+		var peerCells []MatrixCell
+		peerCells, peerErrors = fetchPeers(ctx, peers)
+		cells = mergeCells(append(cells, peerCells...))
+	}
+	return newMatrix(window, cells, peerErrors)
+}
+
+func newMatrix(window time.Duration, cells []MatrixCell, peerErrors map[string]string) Matrix {
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Destination < b.Destination
+	})
+	return Matrix{
+		GeneratedAt:  time.Now().UTC(),
+		Window:       window.String(),
+		Sources:      distinct(cells, func(c MatrixCell) string { return c.Source }),
+		Destinations: distinct(cells, func(c MatrixCell) string { return c.Destination }),
+		Kinds:        distinct(cells, func(c MatrixCell) string { return c.Kind }),
+		Cells:        cells,
+		PeerErrors:   peerErrors,
+	}
+}
+
+func distinct(cells []MatrixCell, field func(MatrixCell) string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, c := range cells {
+		if v := field(c); !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// mergeCells combines cells with the same source, destination and kind, e.g.
+// from two instances in the same region. Success rates are merged exactly;
+// percentiles are approximated by their sample-weighted average.
+func mergeCells(cells []MatrixCell) []MatrixCell {
+	merged := make(map[matrixKey]*MatrixCell)
+	var order []matrixKey
+	for _, c := range cells {
+		key := matrixKey{Source: c.Source, Destination: c.Destination, Kind: c.Kind}
+		m, ok := merged[key]
+		if !ok {
+			c := c
+			merged[key] = &c
+			order = append(order, key)
+			continue
+		}
+		total := float64(m.Samples + c.Samples)
+		if total == 0 {
+			continue
+		}
+		wm, wc := float64(m.Samples)/total, float64(c.Samples)/total
+		m.SuccessRate = m.SuccessRate*wm + c.SuccessRate*wc
+		m.P50Ms = m.P50Ms*wm + c.P50Ms*wc
+		m.P99Ms = m.P99Ms*wm + c.P99Ms*wc
+		m.Samples += c.Samples
+	}
+	out := make([]MatrixCell, 0, len(order))
+	for _, key := range order {
+		out = append(out, *merged[key])
+	}
+	return out
+}
+
+// fetchPeers fetches the local matrix of every peer concurrently.
+func fetchPeers(ctx context.Context, peers []string) ([]MatrixCell, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var cells []MatrixCell
+	errs := make(map[string]string)
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			got, err := fetchPeer(ctx, peer)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("[Matrix] peer %s: %v", peer, err)
+				errs[peer] = err.Error()
+				return
+			}
+			cells = append(cells, got...)
+		}(peer)
+	}
+	wg.Wait()
+	if len(errs) == 0 {
+		errs = nil
+	}
+	return cells, errs
+}
+
+func fetchPeer(ctx context.Context, peer string) ([]MatrixCell, error) {
+	u, err := url.Parse(peer)
+	if err != nil {
+		return nil, err
+	}
+	// Ask only for the peer's own results, so peers listing each other don't recurse.
+	q := u.Query()
+	q.Set("local", "1")
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	var m Matrix
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m.Cells, nil
+}
+
+// MatrixHandler serves the source×destination matrix of success rate and
+// latency per probe kind, as JSON or, with ?format=html or a browser Accept
+// header, as an HTML table. ?local=1 skips the peers.
+func MatrixHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := regionMatrix.build(r.Context(), r.URL.Query().Get("local") == "")
+		format := r.URL.Query().Get("format")
+		if format == "html" || (format == "" && strings.Contains(r.Header.Get("Accept"), "text/html")) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := matrixTemplate.Execute(w, m); err != nil {
+				log.Printf("[Matrix] render: %v", err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+	})
+}
+
+// Cell returns the cell for source, destination and kind, if any.
+func (m Matrix) Cell(kind, source, destination string) *MatrixCell {
+	for i := range m.Cells {
+		c := &m.Cells[i]
+		if c.Kind == kind && c.Source == source && c.Destination == destination {
+			return c
+		}
+	}
+	return nil
+}
+
+var matrixTemplate = template.Must(template.New("matrix").Funcs(template.FuncMap{
+	"pct": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"ms":  func(f float64) string { return fmt.Sprintf("%.1fms", f) },
+	"color": func(f float64) string {
+		switch {
+		case f >= 0.99:
+			return "#c8e6c9"
+		case f >= 0.9:
+			return "#fff9c4"
+		default:
+			return "#ffcdd2"
+		}
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><title>Prober region matrix</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: center; }
+td small { color: #555; }
+</style></head>
+<body>
+<h1>Region matrix</h1>
+<p>Window: {{.Window}} &middot; generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+{{range $peer, $err := .PeerErrors}}<p style="color:#b71c1c">Peer {{$peer}}: {{$err}}</p>{{end}}
+{{$m := .}}
+{{range $kind := .Kinds}}
+<h2>{{$kind}}</h2>
+<table>
+<tr><th>source &darr; / destination &rarr;</th>{{range $m.Destinations}}<th>{{.}}</th>{{end}}</tr>
+{{range $src := $m.Sources}}
+<tr><th>{{$src}}</th>
+{{range $dst := $m.Destinations}}{{with $m.Cell $kind $src $dst}}<td style="background:{{color .SuccessRate}}">{{pct .SuccessRate}}<br><small>p50 {{ms .P50Ms}} &middot; p99 {{ms .P99Ms}} &middot; n={{.Samples}}</small></td>{{else}}<td>&ndash;</td>{{end}}{{end}}
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No results yet.</p>
+{{end}}
+</body></html>
+`))
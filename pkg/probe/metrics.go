@@ -0,0 +1,276 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// baseLabelNames are the labels every probe series carries; the extra labels
+// of a vector and then the custom labels from the config follow them.
+var baseLabelNames = []string{"target_type", "operation_type", "target_name", "source_region", "destination_region", "source_node_name", "source_node_ip"}
+
+var (
+	metricsMu        sync.RWMutex
+	customLabelNames []string
+	dynamicVecs      []dynamicVec
+)
+
+var (
+	successCounter = NewCounterVec(prometheus.CounterOpts{
+		Name: "prober_success_total",
+		Help: "Total successful probe operations",
+	})
+	failureCounter = NewCounterVec(prometheus.CounterOpts{
+		Name: "prober_failure_total",
+		Help: "Total failed probe operations",
+	})
+)
+
+var infoDesc = prometheus.NewDesc(
+	"prober_info",
+	"Identity of the prober instance; always 1",
+	[]string{"source_region", "source_node_name", "source_node_ip", "provider"}, nil,
+)
+
+var probeRegistry = newProbeRegistry()
+
+func newProbeRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(probeCollector{})
+	return r
+}
+
+// MetricsHandler serves the probe metrics, for embedding in another HTTP server.
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(probeRegistry, promhttp.HandlerOpts{})
+}
+
+// DefaultListenAddr is where metrics are served when no address is configured.
+const DefaultListenAddr = "127.0.0.1:2112"
+
+// InitMetrics serves the metrics on /metrics and the region matrix on
+// /matrix, at addr (default DefaultListenAddr).
+func InitMetrics(addr string) {
+	if addr == "" {
+		addr = DefaultListenAddr
+	}
+	go func() {
+		http.Handle("/metrics", MetricsHandler())
+		http.Handle("/matrix", MatrixHandler())
+		http.ListenAndServe(addr, nil)
+	}()
+}
+
+// isReservedLabel reports whether name is a base label or an extra label of
+// some metric vector, which custom labels may not reuse.
+func isReservedLabel(name string) bool {
+	for _, l := range baseLabelNames {
+		if l == name {
+			return true
+		}
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	for _, v := range dynamicVecs {
+		for _, l := range v.extraLabels() {
+			if l == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConfigureLabels re-creates the probe metric vectors with the given custom
+// label keys appended. It is a no-op when the keys are unchanged; otherwise
+// the old series are dropped.
+func ConfigureLabels(keys []string) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if strings.Join(keys, ",") == strings.Join(customLabelNames, ",") {
+		return
+	}
+	customLabelNames = append([]string{}, keys...)
+	for _, v := range dynamicVecs {
+		v.rebuild(customLabelNames)
+	}
+}
+
+// probeCollector forwards to the current metric vectors. It describes no
+// metrics, which makes it an unchecked collector: Prometheus doesn't allow a
+// registered metric to change its label names, but the vectors are swapped
+// whenever the custom label keys change.
+type probeCollector struct{}
+
+func (probeCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (probeCollector) Collect(ch chan<- prometheus.Metric) {
+	id := SourceIdentity()
+	ch <- prometheus.MustNewConstMetric(infoDesc, prometheus.GaugeValue, 1, id.Region, id.NodeName, id.NodeIP, id.Provider)
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	for _, v := range dynamicVecs {
+		v.collect(ch)
+	}
+}
+
+type dynamicVec interface {
+	extraLabels() []string
+	rebuild(customLabels []string)
+	collect(ch chan<- prometheus.Metric)
+}
+
+// vec holds the current Prometheus vector of a CounterVec, GaugeVec or
+// HistogramVec and re-creates it when the custom labels change.
+type vec[T prometheus.Collector] struct {
+	extra []string
+	build func(labelNames []string) T
+	cur   T
+}
+
+func (v *vec[T]) extraLabels() []string { return v.extra }
+
+func (v *vec[T]) rebuild(customLabels []string) {
+	names := append(append(append([]string{}, baseLabelNames...), v.extra...), customLabels...)
+	v.cur = v.build(names)
+}
+
+func (v *vec[T]) collect(ch chan<- prometheus.Metric) { v.cur.Collect(ch) }
+
+func registerVec[T prometheus.Collector](extra []string, build func([]string) T) *vec[T] {
+	v := &vec[T]{extra: extra, build: build}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	v.rebuild(customLabelNames)
+	dynamicVecs = append(dynamicVecs, v)
+	return v
+}
+
+// labelValues returns the values of the base labels, then extra, then the
+// configured custom labels. Custom keys the target doesn't set are left empty.
+// metricsMu must be held.
+func (t Target) labelValues(extra []string) []string {
+	id := SourceIdentity()
+	values := []string{t.Kind, t.Operation, t.Name, t.SourceRegion, t.DestinationRegion, id.NodeName, id.NodeIP}
+	values = append(values, extra...)
+	for _, k := range customLabelNames {
+		values = append(values, t.Labels[k])
+	}
+	return values
+}
+
+// CounterVec is a counter labelled with the standard probe labels of the run
+// in the context, its own extra labels, and the custom config labels.
+type CounterVec struct{ v *vec[*prometheus.CounterVec] }
+
+// NewCounterVec creates and registers a CounterVec. It is meant to be called
+// from package-level variable declarations.
+func NewCounterVec(opts prometheus.CounterOpts, extraLabels ...string) *CounterVec {
+	return &CounterVec{registerVec(extraLabels, func(names []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(opts, names)
+	})}
+}
+
+// Add adds val to the series of the run in ctx; extraValues are the values
+// of the extra labels. It is a no-op when ctx carries no run.
+func (c *CounterVec) Add(ctx context.Context, val float64, extraValues ...string) {
+	if t, ok := TargetFromContext(ctx); ok {
+		c.add(t, val, extraValues...)
+	}
+}
+
+// Inc adds 1 to the series of the run in ctx.
+func (c *CounterVec) Inc(ctx context.Context, extraValues ...string) {
+	c.Add(ctx, 1, extraValues...)
+}
+
+func (c *CounterVec) add(t Target, val float64, extraValues ...string) {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	c.v.cur.WithLabelValues(t.labelValues(extraValues)...).Add(val)
+}
+
+// GaugeVec is a gauge labelled like CounterVec.
+type GaugeVec struct{ v *vec[*prometheus.GaugeVec] }
+
+// NewGaugeVec creates and registers a GaugeVec.
+func NewGaugeVec(opts prometheus.GaugeOpts, extraLabels ...string) *GaugeVec {
+	return &GaugeVec{registerVec(extraLabels, func(names []string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(opts, names)
+	})}
+}
+
+// Set sets the series of the run in ctx to val.
+func (g *GaugeVec) Set(ctx context.Context, val float64, extraValues ...string) {
+	t, ok := TargetFromContext(ctx)
+	if !ok {
+		return
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	g.v.cur.WithLabelValues(t.labelValues(extraValues)...).Set(val)
+}
+
+// Reset removes the series of the run in ctx whose extra labels match extra,
+// e.g. to drop an info series whose label values changed.
+func (g *GaugeVec) Reset(ctx context.Context, extra prometheus.Labels) {
+	t, ok := TargetFromContext(ctx)
+	if !ok {
+		return
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	g.v.cur.DeletePartialMatch(t.partialLabels(extra))
+}
+
+// partialLabels returns the base labels of t merged with extra, to match the
+// series of t with DeletePartialMatch. metricsMu must be held.
+func (t Target) partialLabels(extra prometheus.Labels) prometheus.Labels {
+	labels := prometheus.Labels{}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	for i, v := range t.labelValues(nil)[:len(baseLabelNames)] {
+		labels[baseLabelNames[i]] = v
+	}
+	return labels
+}
+
+// HistogramVec is a histogram labelled like CounterVec.
+type HistogramVec struct {
+	v *vec[*prometheus.HistogramVec]
+}
+
+// NewHistogramVec creates and registers a HistogramVec.
+func NewHistogramVec(opts prometheus.HistogramOpts, extraLabels ...string) *HistogramVec {
+	return &HistogramVec{registerVec(extraLabels, func(names []string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(opts, names)
+	})}
+}
+
+// Observe records val in the series of the run in ctx.
+func (h *HistogramVec) Observe(ctx context.Context, val float64, extraValues ...string) {
+	t, ok := TargetFromContext(ctx)
+	if !ok {
+		return
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	h.v.cur.WithLabelValues(t.labelValues(extraValues)...).Observe(val)
+}
+
+// Reset removes the series of the run in ctx whose extra labels match extra.
+func (h *HistogramVec) Reset(ctx context.Context, extra prometheus.Labels) {
+	t, ok := TargetFromContext(ctx)
+	if !ok {
+		return
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	h.v.cur.DeletePartialMatch(t.partialLabels(extra))
+}
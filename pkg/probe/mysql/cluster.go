@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type MySQLTasks struct {
+	Read  bool `yaml:"read"`
+	Write bool `yaml:"write"`
+}
+
+type MySQLCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	ReadHosts         []string         `yaml:"read_hosts"`
+	WriteHosts        []string         `yaml:"write_hosts"`
+	User              string           `yaml:"user"`
+	Password          string           `yaml:"password"`
+	Database          string           `yaml:"database"`
+	ReadQuery         string           `yaml:"read_query"`
+	WriteQuery        string           `yaml:"write_query"`
+	Tasks             MySQLTasks       `yaml:"tasks"`
+	TLS               tlsconfig.Config `yaml:"tls"`
+}
+
+func (c MySQLCluster) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	probe.Register("mysql", newProbers)
+}
+
+func newProbers(cluster MySQLCluster) ([]probe.Prober, error) {
+	var probers []probe.Prober
+	if cluster.Tasks.Read {
+		for _, host := range cluster.ReadHosts {
+			probers = append(probers, NewReadProbe(host, cluster.User, cluster.Database,
+				WithPassword(cluster.Password), WithQuery(cluster.ReadQuery), WithRegion(cluster.Region), WithTLS(cluster.TLS)))
+		}
+	}
+	if cluster.Tasks.Write {
+		for _, host := range cluster.WriteHosts {
+			probers = append(probers, NewWriteProbe(host, cluster.User, cluster.Database,
+				WithPassword(cluster.Password), WithQuery(cluster.WriteQuery), WithRegion(cluster.Region), WithTLS(cluster.TLS)))
+		}
+	}
+	return probers, nil
+}
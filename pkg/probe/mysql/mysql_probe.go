@@ -0,0 +1,223 @@
+// Package mysql probes MySQL servers with read and write queries.
+package mysql
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type ReadProbe struct {
+	Region   string
+	Host     string
+	User     string
+	Password string
+	Database string
+	Query    string
+	TLS      *tls.Config
+	DB       *sql.DB
+}
+
+type options struct {
+	password string
+	query    string
+	region   string
+	tls      *tlsconfig.Config
+}
+
+// Option configures the MySQL probes.
+type Option func(*options)
+
+// WithPassword sets the password used to connect.
+func WithPassword(password string) Option {
+	return func(o *options) { o.password = password }
+}
+
+// WithQuery sets the query to run; it must return a single row with the value 1
+// (default "SELECT 1").
+func WithQuery(query string) Option {
+	return func(o *options) { o.query = query }
+}
+
+// WithRegion sets the region of the probed host.
+func WithRegion(region string) Option {
+	return func(o *options) { o.region = region }
+}
+
+// WithTLS connects over TLS when c is enabled.
+func WithTLS(c tlsconfig.Config) Option {
+	return func(o *options) {
+		if c.Enabled {
+			o.tls = &c
+		}
+	}
+}
+
+// tlsConfig returns the TLS config for host, or nil without TLS.
+func (o options) tlsConfig(host string) *tls.Config {
+	if o.tls == nil {
+		return nil
+	}
+	return o.tls.ClientConfigFor(tlsconfig.ServerName(host))
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewReadProbe creates a ReadProbe; the DB connection is opened on the first probe
+func NewReadProbe(host, user, database string, opts ...Option) *ReadProbe {
+	o := newOptions(opts)
+	return &ReadProbe{
+		Region:   o.region,
+		Host:     host,
+		User:     user,
+		Password: o.password,
+		Database: database,
+		Query:    o.query,
+		TLS:      o.tlsConfig(host),
+		DB:       nil,
+	}
+}
+
+// NewWriteProbe creates a WriteProbe; the DB connection is opened on the first probe
+func NewWriteProbe(host, user, database string, opts ...Option) *WriteProbe {
+	o := newOptions(opts)
+	return &WriteProbe{
+		Region:   o.region,
+		Host:     host,
+		User:     user,
+		Password: o.password,
+		Database: database,
+		Query:    o.query,
+		TLS:      o.tlsConfig(host),
+		DB:       nil,
+	}
+}
+
+// openDB opens a connection pool to host, using tlsConfig if not nil.
+func openDB(host, user, password, database string, tlsConfig *tls.Config) (*sql.DB, error) {
+	cfg := driver.NewConfig()
+	cfg.User = user
+	cfg.Passwd = password
+	cfg.Net = "tcp"
+	cfg.Addr = host
+	cfg.DBName = database
+	if tlsConfig != nil {
+		cfg.TLS = tlsConfig.Clone()
+	}
+	connector, err := driver.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+func (p *ReadProbe) Probe(ctx context.Context) error {
+	if os.Getenv("DEBUG") == "1" {
+		log.Printf("[DEBUG][MySQL][%s] Executing query: %s", p.Host, p.Query)
+	}
+	if p.Host == "" || p.User == "" || p.Database == "" {
+		// Noop if config is incomplete
+		return nil
+	}
+	if p.DB == nil {
+		db, err := openDB(p.Host, p.User, p.Password, p.Database, p.TLS)
+		if err != nil {
+			return err
+		}
+		p.DB = db
+	}
+	var one int
+	query := p.Query
+	if query == "" {
+		query = "SELECT 1"
+	}
+	err := p.DB.QueryRowContext(ctx, query).Scan(&one)
+	if err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("unexpected result from query: %d", one)
+	}
+	return nil
+}
+
+func (p *ReadProbe) Close() {
+	if p.DB != nil {
+		p.DB.Close()
+	}
+}
+
+func (p *ReadProbe) MetadataString() string {
+	return fmt.Sprintf("Host: %s , Database: %s , User: %s , Region: %s", p.Host, p.Database, p.User, p.Region)
+}
+
+func (p *ReadProbe) Operation() string {
+	return "read"
+}
+
+type WriteProbe struct {
+	Region   string
+	Host     string
+	User     string
+	Password string
+	Database string
+	Query    string
+	TLS      *tls.Config
+	DB       *sql.DB
+}
+
+func (p *WriteProbe) Probe(ctx context.Context) error {
+	if os.Getenv("DEBUG") == "1" {
+		log.Printf("[DEBUG][MySQL][%s] Executing query: %s", p.Host, p.Query)
+	}
+	if p.Host == "" || p.User == "" || p.Database == "" {
+		// Noop if config is incomplete
+		return nil
+	}
+	if p.DB == nil {
+		db, err := openDB(p.Host, p.User, p.Password, p.Database, p.TLS)
+		if err != nil {
+			return err
+		}
+		p.DB = db
+	}
+	var one int
+	query := p.Query
+	if query == "" {
+		query = "SELECT 1"
+	}
+	err := p.DB.QueryRowContext(ctx, query).Scan(&one)
+	if err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("unexpected result from query: %d", one)
+	}
+	return nil
+}
+
+func (p *WriteProbe) Close() {
+	if p.DB != nil {
+		p.DB.Close()
+	}
+}
+
+func (p *WriteProbe) MetadataString() string {
+	return fmt.Sprintf("Host: %s , Database: %s , User: %s , Region: %s", p.Host, p.Database, p.User, p.Region)
+}
+
+func (p *WriteProbe) Operation() string {
+	return "write"
+}
@@ -0,0 +1,154 @@
+package probe
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of a single probe run.
+type Result struct {
+	Kind              string
+	Cluster           string
+	TargetType        string // e.g. REDIS_WRITE
+	Operation         string
+	SourceRegion      string
+	DestinationRegion string
+	Err               error
+	Details           string
+	Duration          time.Duration
+}
+
+func logResult(m Result) {
+	status := "OK "
+	if m.Err != nil {
+		status = "ERR"
+	}
+	log.Printf("[ProbeResult ] status: %v | target_type: %-25v | cluster: %-20v | details: %-120v | Error: %v", status, m.TargetType, m.Cluster, m.Details, m.Err)
+}
+
+// runCluster runs the probers of one cluster every interval and reports their
+// results until ctx is cancelled.
+func runCluster(ctx context.Context, kind string, cluster ClusterBase, labels map[string]string, interval time.Duration, probers []Prober, onResult func(Result)) {
+	sourceRegion := SourceIdentity().Region
+	if cluster.Region == "" {
+		log.Printf("ERROR: region missing for %s cluster %s (source region: %s)", kind, cluster.Name, sourceRegion)
+	}
+	ms := int(interval.Milliseconds())
+	if ms < 100 {
+		ms = 100
+	}
+
+	// Each cluster gets its own status channel
+	statusCh := make(chan Result, 10)
+	for _, p := range probers {
+		op := operationOf(p)
+		targetType := strings.ToUpper(kind)
+		if op != "probe" {
+			targetType += "_" + strings.ToUpper(op)
+		}
+		result := Result{
+			Kind:              kind,
+			Cluster:           cluster.Name,
+			TargetType:        targetType,
+			Operation:         op,
+			SourceRegion:      sourceRegion,
+			DestinationRegion: cluster.Region,
+		}
+		target := Target{
+			Kind:              kind,
+			Operation:         op,
+			Name:              cluster.Name,
+			SourceRegion:      sourceRegion,
+			DestinationRegion: cluster.Region,
+			Labels:            labels,
+		}
+		launchProbeWithDuration(ctx, ms, result, target, p, statusCh)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-statusCh:
+			regionMatrix.record(m)
+			onResult(m)
+		}
+	}
+}
+
+// launchProbeWithDuration runs probe every ms milliseconds and sends a copy of
+// result, filled in with the outcome, to statusCh after each run. Each run
+// gets a context carrying target, so the probe can record its own metrics
+// and details.
+func launchProbeWithDuration(ctx context.Context, ms int, result Result, target Target, probe Prober, statusCh chan<- Result) {
+	go func() {
+		if c, ok := probe.(Closer); ok {
+			defer c.Close()
+		}
+		ticker := newTickerWithContext(ctx, ms)
+		defer ticker.Stop()
+		for range ticker.C {
+			runCtx := ContextWithTarget(ctx, target)
+			start := time.Now()
+			err := probe.Probe(runCtx)
+			elapsed := time.Since(start)
+			if err != nil {
+				failureCounter.add(target, 1)
+			} else {
+				successCounter.add(target, 1)
+			}
+			m := result
+			m.Err = err
+			m.Details = probe.MetadataString()
+			if details := runDetails(runCtx); details != "" {
+				m.Details += " , " + details
+			}
+			m.Duration = elapsed
+			select {
+			case statusCh <- m:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// newTickerWithContext returns a ticker that stops when ctx is done.
+func newTickerWithContext(ctx context.Context, ms int) *tickerWithContext {
+	t := &tickerWithContext{
+		C:    make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+	go func() {
+		tick := make(chan struct{}, 1)
+		go func() {
+			for {
+				tick <- struct{}{}
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				close(t.C)
+				return
+			case <-t.stop:
+				close(t.C)
+				return
+			case <-tick:
+				t.C <- struct{}{}
+			}
+		}
+	}()
+	return t
+}
+
+type tickerWithContext struct {
+	C    chan struct{}
+	stop chan struct{}
+}
+
+func (t *tickerWithContext) Stop() {
+	close(t.stop)
+}
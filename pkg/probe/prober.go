@@ -0,0 +1,34 @@
+// Package probe runs periodic health probes against infrastructure services
+// and exports their results as Prometheus metrics. The probe kinds live in the
+// subpackages (tcp, http, redis, mysql, s3, kafka), which can also be used on
+// their own.
+package probe
+
+import "context"
+
+// Prober is a single health check. Probe returns nil when the target is healthy.
+type Prober interface {
+	Probe(ctx context.Context) error
+	MetadataString() string
+}
+
+// Operator is implemented by probers that report a specific operation type,
+// such as "read" or "write". Probers that don't implement it report "probe".
+type Operator interface {
+	Operation() string
+}
+
+// operationOf returns the operation type a prober reports under.
+func operationOf(p Prober) string {
+	if o, ok := p.(Operator); ok {
+		return o.Operation()
+	}
+	return "probe"
+}
+
+// Closer is implemented by probers that hold connections or clients. Close is
+// called once the prober's cluster is stopped or replaced, after its last
+// Probe has returned.
+type Closer interface {
+	Close()
+}
@@ -0,0 +1,207 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type RedisTasks struct {
+	Read  bool `yaml:"read"`
+	Write bool `yaml:"write"`
+	// Info reads INFO from every node and checks it against the info
+	// thresholds; only `redis` clusters run it.
+	Info bool `yaml:"info"`
+}
+
+// Timeouts are the connection timeouts of the Redis clients; an unset one
+// keeps the go-redis default (dial 5s, read and write 3s).
+type Timeouts struct {
+	DialTimeout  probe.DurationString `yaml:"dialTimeout"`
+	ReadTimeout  probe.DurationString `yaml:"readTimeout"`
+	WriteTimeout probe.DurationString `yaml:"writeTimeout"`
+}
+
+func (t Timeouts) validate() error {
+	for _, d := range []struct {
+		name  string
+		value probe.DurationString
+	}{{"dialTimeout", t.DialTimeout}, {"readTimeout", t.ReadTimeout}, {"writeTimeout", t.WriteTimeout}} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(string(d.value))
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		if v <= 0 {
+			return fmt.Errorf("%s must be positive", d.name)
+		}
+	}
+	return nil
+}
+
+func (t Timeouts) option() Option {
+	return WithTimeouts(t.DialTimeout.ToDuration(0), t.ReadTimeout.ToDuration(0), t.WriteTimeout.ToDuration(0))
+}
+
+type RedisCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Nodes             []string         `yaml:"nodes"`
+	Username          string           `yaml:"username"`
+	Password          string           `yaml:"password"`
+	Tasks             RedisTasks       `yaml:"tasks"`
+	TLS               tlsconfig.Config `yaml:"tls"`
+	DB                int              `yaml:"db"`
+	// KeyPrefix prefixes the key of the write probes (default
+	// "probe_key_").
+	KeyPrefix string         `yaml:"keyPrefix"`
+	Timeouts  Timeouts       `yaml:",inline"`
+	Info      InfoThresholds `yaml:"info"`
+}
+
+// RedisSentinelCluster is a `redisSentinel` cluster: a master and its
+// replicas, found through sentinels.
+type RedisSentinelCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Sentinels         []string `yaml:"sentinels"`
+	MasterName        string   `yaml:"masterName"`
+	Username          string   `yaml:"username"`
+	Password          string   `yaml:"password"`
+	SentinelPassword  string   `yaml:"sentinelPassword"`
+	DB                int      `yaml:"db"`
+	KeyPrefix         string   `yaml:"keyPrefix"`
+	// Write runs the write probe on the master and Read a PING on a
+	// replica; the sentinels are always checked.
+	Tasks    RedisTasks       `yaml:"tasks"`
+	TLS      tlsconfig.Config `yaml:"tls"`
+	Timeouts Timeouts         `yaml:",inline"`
+}
+
+type RedisClusterCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Nodes             []string         `yaml:"nodes"`
+	Username          string           `yaml:"username"`
+	Password          string           `yaml:"password"`
+	TLS               tlsconfig.Config `yaml:"tls"`
+	// DB is accepted for symmetry with `redis` clusters but must be 0:
+	// Redis Cluster only has database 0.
+	DB int `yaml:"db"`
+	// KeyPrefix prefixes the keys of the round trips on the masters
+	// (default "probe_key_").
+	KeyPrefix string   `yaml:"keyPrefix"`
+	Timeouts  Timeouts `yaml:",inline"`
+}
+
+func (c RedisCluster) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if c.DB < 0 {
+		return fmt.Errorf("db must not be negative")
+	}
+	if err := c.Info.validate(); err != nil {
+		return fmt.Errorf("info: %w", err)
+	}
+	return c.Timeouts.validate()
+}
+
+func (c RedisSentinelCluster) Validate() error {
+	if len(c.Sentinels) == 0 || c.MasterName == "" {
+		return fmt.Errorf("sentinels and masterName are required")
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if c.DB < 0 {
+		return fmt.Errorf("db must not be negative")
+	}
+	return c.Timeouts.validate()
+}
+
+func (c RedisClusterCluster) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if c.DB != 0 {
+		return fmt.Errorf("db must be 0, Redis Cluster has no other database")
+	}
+	return c.Timeouts.validate()
+}
+
+func init() {
+	probe.Register("redis", newProbers)
+	probe.Register("redisCluster", newClusterProbers)
+	probe.Register("redisSentinel", newSentinelProbers)
+}
+
+func newProbers(cluster RedisCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithUsername(cluster.Username),
+		WithPassword(cluster.Password),
+		WithRegion(cluster.Region),
+		WithTLS(cluster.TLS),
+		WithDB(cluster.DB),
+		cluster.Timeouts.option(),
+	}
+	if cluster.KeyPrefix != "" {
+		opts = append(opts, WithKeyPrefix(cluster.KeyPrefix))
+	}
+	var probers []probe.Prober
+	if cluster.Tasks.Read {
+		for _, node := range cluster.Nodes {
+			probers = append(probers, NewReadProbe(node, opts...))
+		}
+	}
+	if cluster.Tasks.Write {
+		for _, node := range cluster.Nodes {
+			probers = append(probers, NewWriteProbe(node, opts...))
+		}
+	}
+	if cluster.Tasks.Info {
+		for _, node := range cluster.Nodes {
+			probers = append(probers, NewInfoProbe(node, cluster.Info, opts...))
+		}
+	}
+	return probers, nil
+}
+
+func newClusterProbers(cluster RedisClusterCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithUsername(cluster.Username),
+		WithPassword(cluster.Password),
+		WithRegion(cluster.Region),
+		WithTLS(cluster.TLS),
+		cluster.Timeouts.option(),
+	}
+	if cluster.KeyPrefix != "" {
+		opts = append(opts, WithKeyPrefix(cluster.KeyPrefix))
+	}
+	p := NewClusterProbe(cluster.Nodes, opts...)
+	return []probe.Prober{p}, nil
+}
+
+func newSentinelProbers(cluster RedisSentinelCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithUsername(cluster.Username),
+		WithPassword(cluster.Password),
+		WithSentinelPassword(cluster.SentinelPassword),
+		WithRegion(cluster.Region),
+		WithTLS(cluster.TLS),
+		WithDB(cluster.DB),
+		cluster.Timeouts.option(),
+	}
+	if cluster.KeyPrefix != "" {
+		opts = append(opts, WithKeyPrefix(cluster.KeyPrefix))
+	}
+	probers := []probe.Prober{NewSentinelProbe(cluster.Sentinels, cluster.MasterName, opts...)}
+	if cluster.Tasks.Read {
+		probers = append(probers, NewSentinelReadProbe(cluster.Sentinels, cluster.MasterName, opts...))
+	}
+	if cluster.Tasks.Write {
+		probers = append(probers, NewSentinelWriteProbe(cluster.Sentinels, cluster.MasterName, opts...))
+	}
+	return probers, nil
+}
@@ -0,0 +1,238 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+var (
+	replicationLag = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_replication_offset_lag_bytes",
+		Help: "Replication offset of a master minus the offset acknowledged by each of its replicas",
+	}, "node", "replica")
+	replicationLinkUp = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_replication_link_up",
+		Help: "Whether the replication link is up: to the master on a replica, to each replica (state online) on a master",
+	}, "node", "peer")
+	memoryUsed = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_memory_used_bytes",
+		Help: "used_memory from INFO memory",
+	}, "node")
+	memoryMax = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_memory_max_bytes",
+		Help: "maxmemory from INFO memory; 0 means no limit",
+	}, "node")
+	persistenceOK = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_persistence_ok",
+		Help: "Whether the last RDB save (persistence=rdb) or AOF write (persistence=aof, only with AOF enabled) succeeded",
+	}, "node", "persistence")
+	connectedClients = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_connected_clients",
+		Help: "connected_clients from INFO clients",
+	}, "node")
+)
+
+// InfoThresholds turn the values of INFO into probe failures; a zero
+// threshold is not checked.
+type InfoThresholds struct {
+	// MaxReplicationLagBytes fails a master when a replica's acknowledged
+	// offset is further behind.
+	MaxReplicationLagBytes int64 `yaml:"maxReplicationLagBytes"`
+	// RequireLinkUp fails a replica whose link to the master is down and a
+	// master with a replica that is not online.
+	RequireLinkUp bool `yaml:"requireLinkUp"`
+	// MaxMemoryRatio fails a node whose used_memory exceeds this fraction of
+	// maxmemory; nodes without maxmemory are not checked.
+	MaxMemoryRatio float64 `yaml:"maxMemoryRatio"`
+	// RequirePersistenceOK fails a node whose last RDB save, or last AOF
+	// write or rewrite with AOF enabled, failed.
+	RequirePersistenceOK bool `yaml:"requirePersistenceOK"`
+	MaxConnectedClients  int  `yaml:"maxConnectedClients"`
+}
+
+func (t InfoThresholds) validate() error {
+	if t.MaxReplicationLagBytes < 0 || t.MaxConnectedClients < 0 {
+		return fmt.Errorf("maxReplicationLagBytes and maxConnectedClients must not be negative")
+	}
+	if t.MaxMemoryRatio < 0 || t.MaxMemoryRatio > 1 {
+		return fmt.Errorf("maxMemoryRatio must be between 0 and 1")
+	}
+	return nil
+}
+
+// InfoProbe reads INFO from a node, exports its replication, memory,
+// persistence and client figures and checks them against Thresholds.
+type InfoProbe struct {
+	Region     string
+	Addr       string
+	Thresholds InfoThresholds
+	client     *redis.Client
+	connect    func() *redis.Client
+}
+
+// NewInfoProbe creates an InfoProbe with a persistent client
+func NewInfoProbe(addr string, thresholds InfoThresholds, opts ...Option) *InfoProbe {
+	o := newOptions(opts)
+	p := &InfoProbe{
+		Region:     o.region,
+		Addr:       addr,
+		Thresholds: thresholds,
+	}
+	p.connect = func() *redis.Client { return redis.NewClient(o.clientOptions(addr)) }
+	p.client = p.connect()
+	return p
+}
+
+func (p *InfoProbe) Probe(ctx context.Context) error {
+	// INFO without a section covers replication, memory, persistence and
+	// clients, also on servers that take only one section.
+	info, err := p.client.Info(ctx).Result()
+	if err != nil {
+		// Try to reconnect once
+		p.client.Close()
+		p.client = p.connect()
+		if info, err = p.client.Info(ctx).Result(); err != nil {
+			return err
+		}
+	}
+	fields := parseInfo(info)
+	var errs []string
+	errs = append(errs, p.checkReplication(ctx, fields)...)
+	errs = append(errs, p.checkMemory(ctx, fields)...)
+	errs = append(errs, p.checkPersistence(ctx, fields)...)
+
+	clients, _ := strconv.Atoi(fields["connected_clients"])
+	connectedClients.Set(ctx, float64(clients), p.Addr)
+	if max := p.Thresholds.MaxConnectedClients; max > 0 && clients > max {
+		errs = append(errs, fmt.Sprintf("%d connected clients, max %d", clients, max))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("redis info errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// nodeLabels selects the series of this node, which share their vectors with
+// the other nodes of the cluster.
+func (p *InfoProbe) nodeLabels() prometheus.Labels {
+	return prometheus.Labels{"node": p.Addr}
+}
+
+func (p *InfoProbe) checkReplication(ctx context.Context, fields map[string]string) []string {
+	var errs []string
+	role := fields["role"]
+	probe.AddDetail(ctx, "Role", role)
+	// Replicas that went away must not keep their last value.
+	replicationLag.Reset(ctx, p.nodeLabels())
+	replicationLinkUp.Reset(ctx, p.nodeLabels())
+	if role == "slave" {
+		master := net.JoinHostPort(fields["master_host"], fields["master_port"])
+		up := fields["master_link_status"] == "up"
+		replicationLinkUp.Set(ctx, boolValue(up), p.Addr, master)
+		if !up && p.Thresholds.RequireLinkUp {
+			errs = append(errs, fmt.Sprintf("link to master %s is %s", master, fields["master_link_status"]))
+		}
+		return errs
+	}
+
+	offset, _ := strconv.ParseInt(fields["master_repl_offset"], 10, 64)
+	n, _ := strconv.Atoi(fields["connected_slaves"])
+	var maxLag int64
+	for i := 0; i < n; i++ {
+		// slave0:ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0
+		replica := infoValues(fields[fmt.Sprintf("slave%d", i)])
+		addr := net.JoinHostPort(replica["ip"], replica["port"])
+		online := replica["state"] == "online"
+		replicationLinkUp.Set(ctx, boolValue(online), p.Addr, addr)
+		if !online && p.Thresholds.RequireLinkUp {
+			errs = append(errs, fmt.Sprintf("replica %s is %s", addr, replica["state"]))
+		}
+		replicaOffset, _ := strconv.ParseInt(replica["offset"], 10, 64)
+		lag := offset - replicaOffset
+		replicationLag.Set(ctx, float64(lag), p.Addr, addr)
+		if lag > maxLag {
+			maxLag = lag
+		}
+		if max := p.Thresholds.MaxReplicationLagBytes; max > 0 && lag > max {
+			errs = append(errs, fmt.Sprintf("replica %s lags %d bytes, max %d", addr, lag, max))
+		}
+	}
+	if n > 0 {
+		probe.AddDetail(ctx, "Replicas", fmt.Sprintf("%d, max lag %d bytes", n, maxLag))
+	}
+	return errs
+}
+
+func (p *InfoProbe) checkMemory(ctx context.Context, fields map[string]string) []string {
+	used, _ := strconv.ParseFloat(fields["used_memory"], 64)
+	max, _ := strconv.ParseFloat(fields["maxmemory"], 64)
+	memoryUsed.Set(ctx, used, p.Addr)
+	memoryMax.Set(ctx, max, p.Addr)
+	if max <= 0 {
+		return nil
+	}
+	ratio := used / max
+	probe.AddDetail(ctx, "Memory", fmt.Sprintf("%.1f%% of maxmemory", ratio*100))
+	if r := p.Thresholds.MaxMemoryRatio; r > 0 && ratio > r {
+		return []string{fmt.Sprintf("used_memory is %.1f%% of maxmemory, max %.1f%%", ratio*100, r*100)}
+	}
+	return nil
+}
+
+func (p *InfoProbe) checkPersistence(ctx context.Context, fields map[string]string) []string {
+	var errs []string
+	persistenceOK.Reset(ctx, p.nodeLabels())
+	rdb := fields["rdb_last_bgsave_status"]
+	if rdb != "" {
+		persistenceOK.Set(ctx, boolValue(rdb == "ok"), p.Addr, "rdb")
+		if rdb != "ok" && p.Thresholds.RequirePersistenceOK {
+			errs = append(errs, fmt.Sprintf("last RDB save status is %s", rdb))
+		}
+	}
+	if fields["aof_enabled"] == "1" {
+		write, rewrite := fields["aof_last_write_status"], fields["aof_last_bgrewrite_status"]
+		ok := write == "ok" && rewrite == "ok"
+		persistenceOK.Set(ctx, boolValue(ok), p.Addr, "aof")
+		if !ok && p.Thresholds.RequirePersistenceOK {
+			errs = append(errs, fmt.Sprintf("last AOF write status is %s, rewrite status %s", write, rewrite))
+		}
+	}
+	return errs
+}
+
+// infoValues parses a "k=v,k=v" INFO value.
+func infoValues(s string) map[string]string {
+	values := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *InfoProbe) Close() {
+	p.client.Close()
+}
+
+func (p *InfoProbe) MetadataString() string {
+	return fmt.Sprintf("Node: %s , Region: %s", p.Addr, p.Region)
+}
+
+func (p *InfoProbe) Operation() string {
+	return "info"
+}
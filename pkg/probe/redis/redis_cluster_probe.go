@@ -0,0 +1,350 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+// clusterSlots is the number of hash slots of a Redis cluster.
+const clusterSlots = 16384
+
+var (
+	clusterStateOK = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_cluster_state_ok",
+		Help: "Whether CLUSTER INFO reports cluster_state:ok",
+	})
+	clusterSlotsAssigned = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_cluster_slots_assigned",
+		Help: "Hash slots assigned across the whole cluster, out of 16384, as reported by CLUSTER INFO",
+	})
+	clusterNodeUp = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_cluster_node_up",
+		Help: "Whether each cluster node passed its check and is not flagged fail or pfail",
+	}, "node", "role")
+	clusterNodeDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prober_redis_cluster_node_duration_seconds",
+		Help:    "Duration of the check of each cluster node: a write round trip on masters, a PING on replicas",
+		Buckets: prometheus.DefBuckets,
+	}, "node", "role")
+)
+
+// ClusterProbe checks the health of a Redis cluster: CLUSTER INFO must
+// report cluster_state:ok with all slots assigned, no node may be flagged
+// fail or pfail, every master must pass a write round trip on a key of one
+// of its slots and every replica must answer a PING.
+type ClusterProbe struct {
+	Region   string
+	Addrs    []string
+	Username string
+	Password string
+	TLS      *tls.Config
+	// client follows the topology for CLUSTER INFO and CLUSTER NODES; nodes
+	// holds a client per node address, for the checks of each node.
+	client *redis.ClusterClient
+	nodes  map[string]*redis.Client
+	// roles are the roles of the nodes of the last run, whose series are
+	// removed once a node leaves the cluster or changes its role.
+	roles map[string]string
+	// suffix ends the keys of the round trips; it is fixed for the lifetime
+	// of the probe.
+	suffix string
+	opts   options
+}
+
+// NewClusterProbe creates a ClusterProbe with a persistent cluster client
+func NewClusterProbe(addrs []string, opts ...Option) *ClusterProbe {
+	o := newOptions(opts)
+	return &ClusterProbe{
+		Region:   o.region,
+		Addrs:    addrs,
+		Username: o.username,
+		Password: o.password,
+		TLS:      o.tls,
+		client:   redis.NewClusterClient(o.clusterOptions(addrs)),
+		nodes:    make(map[string]*redis.Client),
+		suffix:   RandString(12),
+		opts:     o,
+	}
+}
+
+type ShardError struct {
+	Addr string
+	Err  error
+}
+
+func (e ShardError) Error() string {
+	return fmt.Sprintf("Redis cluster shard %s error: %v", e.Addr, e.Err)
+}
+
+// clusterNode is a line of CLUSTER NODES.
+type clusterNode struct {
+	addr  string
+	role  string
+	flags []string
+	// slots are the first slot of each range the node serves.
+	slots []int
+}
+
+// failState returns "fail" or "pfail" when other nodes flag the node as
+// failing, or "".
+func (n clusterNode) failState() string {
+	for _, f := range n.flags {
+		switch f {
+		case "fail":
+			return "fail"
+		case "fail?":
+			return "pfail"
+		}
+	}
+	return ""
+}
+
+// parseClusterNodes parses the output of CLUSTER NODES, skipping nodes
+// still in handshake or without an address.
+func parseClusterNodes(text string) ([]clusterNode, error) {
+	var nodes []clusterNode
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("malformed CLUSTER NODES line %q", line)
+		}
+		n := clusterNode{flags: strings.Split(fields[2], ",")}
+		skip := false
+		for _, f := range n.flags {
+			switch f {
+			case "master":
+				n.role = "master"
+			case "slave":
+				n.role = "replica"
+			case "handshake", "noaddr":
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		// ip:port@cport[,hostname]
+		n.addr, _, _ = strings.Cut(fields[1], "@")
+		for _, r := range fields[8:] {
+			if strings.HasPrefix(r, "[") {
+				continue // slot being imported or migrated
+			}
+			first, _, _ := strings.Cut(r, "-")
+			slot, err := strconv.Atoi(first)
+			if err != nil {
+				return nil, fmt.Errorf("malformed slot range %q of %s", r, n.addr)
+			}
+			n.slots = append(n.slots, slot)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// parseInfo parses the "field:value" lines of INFO and CLUSTER INFO.
+func parseInfo(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+func (p *ClusterProbe) Probe(ctx context.Context) error {
+	var errs []string
+	info, err := p.client.ClusterInfo(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("CLUSTER INFO: %w", err)
+	}
+	fields := parseInfo(info)
+	state := fields["cluster_state"]
+	slots, _ := strconv.Atoi(fields["cluster_slots_assigned"])
+	ok := 0.0
+	if state == "ok" {
+		ok = 1
+	} else {
+		errs = append(errs, fmt.Sprintf("cluster_state is %s", state))
+	}
+	if slots != clusterSlots {
+		errs = append(errs, fmt.Sprintf("%d of %d slots assigned", slots, clusterSlots))
+	}
+	clusterStateOK.Set(ctx, ok)
+	clusterSlotsAssigned.Set(ctx, float64(slots))
+	probe.AddDetail(ctx, "State", state)
+	probe.AddDetail(ctx, "Slots", fmt.Sprintf("%d/%d", slots, clusterSlots))
+
+	text, err := p.client.ClusterNodes(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("CLUSTER NODES: %w", err)
+	}
+	nodes, err := parseClusterNodes(text)
+	if err != nil {
+		return err
+	}
+	p.syncClients(nodes)
+	p.dropStaleNodes(ctx, nodes)
+
+	nodeErrs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			nodeErrs[i] = p.checkNode(ctx, n)
+			clusterNodeDuration.Observe(ctx, time.Since(start).Seconds(), n.addr, n.role)
+			up := 0.0
+			if nodeErrs[i] == nil {
+				up = 1
+			}
+			clusterNodeUp.Set(ctx, up, n.addr, n.role)
+		}()
+	}
+	wg.Wait()
+
+	masters, up := 0, 0
+	for i, n := range nodes {
+		if n.role == "master" {
+			masters++
+		}
+		if nodeErrs[i] != nil {
+			errs = append(errs, nodeErrs[i].Error())
+		} else {
+			up++
+		}
+	}
+	probe.AddDetail(ctx, "Up", fmt.Sprintf("%d/%d nodes, %d masters", up, len(nodes), masters))
+	if len(errs) > 0 {
+		return fmt.Errorf("redis cluster errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkNode fails a node flagged fail or pfail, and otherwise runs a write
+// round trip on a master with slots or a PING on any other node.
+func (p *ClusterProbe) checkNode(ctx context.Context, n clusterNode) error {
+	if s := n.failState(); s != "" {
+		return ShardError{Addr: n.addr, Err: fmt.Errorf("%s flagged %s", n.role, s)}
+	}
+	client := p.nodes[n.addr]
+	var err error
+	if n.role == "master" && len(n.slots) > 0 {
+		// The hash tag puts the key in a slot of this master, so the node
+		// serves it without a redirect.
+		key := p.opts.keyPrefix + "{" + slotTag(n.slots[0]) + "}" + p.suffix
+		err = roundTrip(ctx, client, n.addr, key)
+	} else {
+		err = client.Ping(ctx).Err()
+	}
+	if err != nil {
+		return ShardError{Addr: n.addr, Err: fmt.Errorf("%s: %w", n.role, err)}
+	}
+	return nil
+}
+
+// syncClients opens a client for every new node and closes those of nodes
+// that left the cluster.
+func (p *ClusterProbe) syncClients(nodes []clusterNode) {
+	seen := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		seen[n.addr] = true
+		if p.nodes[n.addr] == nil {
+			p.nodes[n.addr] = redis.NewClient(p.opts.clientOptions(n.addr))
+		}
+	}
+	for addr, client := range p.nodes {
+		if !seen[addr] {
+			client.Close()
+			delete(p.nodes, addr)
+		}
+	}
+}
+
+// dropStaleNodes removes the per-node series of nodes the last run checked
+// but that left the cluster or changed their role since, e.g. after a
+// failover.
+func (p *ClusterProbe) dropStaleNodes(ctx context.Context, nodes []clusterNode) {
+	current := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		current[n.addr] = n.role
+	}
+	for addr, role := range p.roles {
+		r, ok := current[addr]
+		if !ok || r != role {
+			labels := prometheus.Labels{"node": addr, "role": role}
+			clusterNodeUp.Reset(ctx, labels)
+			clusterNodeDuration.Reset(ctx, labels)
+		}
+		if !ok {
+			commandDuration.Reset(ctx, prometheus.Labels{"node": addr})
+		}
+	}
+	p.roles = current
+}
+
+func (p *ClusterProbe) Close() {
+	p.client.Close()
+	for _, client := range p.nodes {
+		client.Close()
+	}
+}
+
+func (p *ClusterProbe) MetadataString() string {
+	return fmt.Sprintf("Nodes: %v , Region: %s", p.Addrs, p.Region)
+}
+
+func (p *ClusterProbe) Operation() string {
+	return "cluster"
+}
+
+var (
+	slotTagsOnce sync.Once
+	slotTags     [clusterSlots]string
+)
+
+// slotTag returns a hash tag whose key slot is slot.
+func slotTag(slot int) string {
+	slotTagsOnce.Do(func() {
+		for i, left := 0, clusterSlots; left > 0; i++ {
+			tag := strconv.Itoa(i)
+			s := keySlot(tag)
+			if slotTags[s] == "" {
+				slotTags[s] = tag
+				left--
+			}
+		}
+	})
+	return slotTags[slot]
+}
+
+// keySlot is the hash slot of a key without a hash tag: CRC16 (XMODEM)
+// modulo 16384.
+func keySlot(key string) int {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % clusterSlots
+}
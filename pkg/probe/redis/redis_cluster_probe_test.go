@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseClusterNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []clusterNode
+		wantErr bool
+	}{
+		{
+			name: "masters and a replica",
+			text: "07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,host4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected\n" +
+				"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922\n" +
+				"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 12000 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]\n",
+			want: []clusterNode{
+				{addr: "127.0.0.1:30004", role: "replica", flags: []string{"slave"}},
+				{addr: "127.0.0.1:30002", role: "master", flags: []string{"master"}, slots: []int{5461}},
+				{addr: "127.0.0.1:30001", role: "master", flags: []string{"myself", "master"}, slots: []int{0, 12000}},
+			},
+		},
+		{
+			name: "failing node",
+			text: "6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 master,fail? - 0 1426238316232 5 connected 10923-16383",
+			want: []clusterNode{
+				{addr: "127.0.0.1:30005", role: "master", flags: []string{"master", "fail?"}, slots: []int{10923}},
+			},
+		},
+		{
+			name: "handshake and noaddr nodes are skipped",
+			text: "a 127.0.0.1:30006@31006 handshake - 0 0 0 connected\n" +
+				"b :0@0 master,noaddr - 0 0 0 disconnected\n" +
+				"c 127.0.0.1:30003@31003 master - 0 0 3 connected 10923-16383",
+			want: []clusterNode{
+				{addr: "127.0.0.1:30003", role: "master", flags: []string{"master"}, slots: []int{10923}},
+			},
+		},
+		{name: "short line", text: "a 127.0.0.1:30001@31001 master - 0 0", wantErr: true},
+		{name: "bad slot range", text: "a 127.0.0.1:30001@31001 master - 0 0 1 connected x-100", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseClusterNodes(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClusterNodes error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseClusterNodes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailState(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{flags: []string{"myself", "master"}, want: ""},
+		{flags: []string{"slave", "fail"}, want: "fail"},
+		{flags: []string{"master", "fail?"}, want: "pfail"},
+	}
+	for _, tt := range tests {
+		if got := (clusterNode{flags: tt.flags}).failState(); got != tt.want {
+			t.Errorf("failState(%v) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	// Examples from the Redis cluster specification and CLUSTER KEYSLOT.
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{key: "123456789", want: 12739},
+		{key: "foo", want: 12182},
+		{key: "bar", want: 5061},
+		{key: "", want: 0},
+	}
+	for _, tt := range tests {
+		if got := keySlot(tt.key); got != tt.want {
+			t.Errorf("keySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSlotTag(t *testing.T) {
+	for _, slot := range []int{0, 1, 5461, 10923, 12739, clusterSlots - 1} {
+		tag := slotTag(slot)
+		if tag == "" {
+			t.Errorf("slotTag(%d) is empty", slot)
+			continue
+		}
+		if got := keySlot(tag); got != slot {
+			t.Errorf("keySlot(slotTag(%d)) = %d", slot, got)
+		}
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	text := "# Replication\r\n" +
+		"role:master\r\n" +
+		"connected_slaves:1\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0\r\n" +
+		"\r\n" +
+		"# Cluster\r\n" +
+		"cluster_state:ok\r\n" +
+		"malformed line\r\n" +
+		"master_replid:8f9a:b\r\n"
+	want := map[string]string{
+		"role":             "master",
+		"connected_slaves": "1",
+		"slave0":           "ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0",
+		"cluster_state":    "ok",
+		"master_replid":    "8f9a:b",
+	}
+	if got := parseInfo(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseInfo = %v, want %v", got, want)
+	}
+}
+
+func TestInfoValues(t *testing.T) {
+	tests := []struct {
+		s    string
+		want map[string]string
+	}{
+		{s: "", want: map[string]string{}},
+		{s: "ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0", want: map[string]string{"ip": "10.0.0.2", "port": "6379", "state": "online", "offset": "1234", "lag": "0"}},
+		{s: "ip=::1,flag,port=", want: map[string]string{"ip": "::1", "port": ""}},
+	}
+	for _, tt := range tests {
+		if got := infoValues(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("infoValues(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
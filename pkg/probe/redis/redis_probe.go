@@ -0,0 +1,332 @@
+// Package redis probes standalone Redis nodes and Redis clusters.
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+// RandString generates a random alphanumeric string of given length
+func RandString(n int) string {
+	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	rand.Seed(time.Now().UnixNano())
+	b := make([]rune, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// defaultKeyPrefix prefixes the key of a write probe.
+const defaultKeyPrefix = "probe_key_"
+
+var commandDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "prober_redis_command_duration_seconds",
+	Help:    "Duration of the Redis commands of write probes per node: set, get and del",
+	Buckets: prometheus.DefBuckets,
+}, "node", "command")
+
+type options struct {
+	username string
+	password string
+	region   string
+	tls      *tls.Config
+	// tlsSettings build the TLS config of each connection.
+	tlsSettings *tlsconfig.Config
+	db          int
+	keyPrefix   string
+	// sentinelPassword authenticates with sentinels.
+	sentinelPassword string
+	// Zero timeouts keep the go-redis defaults.
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// Option configures the Redis probes.
+type Option func(*options)
+
+// WithUsername sets the ACL username used to authenticate.
+func WithUsername(username string) Option {
+	return func(o *options) { o.username = username }
+}
+
+// WithPassword sets the password used to authenticate.
+func WithPassword(password string) Option {
+	return func(o *options) { o.password = password }
+}
+
+// WithRegion sets the region of the probed nodes.
+func WithRegion(region string) Option {
+	return func(o *options) { o.region = region }
+}
+
+// WithTLS connects over TLS when c is enabled.
+func WithTLS(c tlsconfig.Config) Option {
+	return func(o *options) {
+		if c.Enabled {
+			o.tls = c.ClientConfig()
+			o.tlsSettings = &c
+		}
+	}
+}
+
+// WithDB selects the database of standalone nodes (default 0).
+func WithDB(db int) Option {
+	return func(o *options) { o.db = db }
+}
+
+// WithKeyPrefix sets the prefix of the key write probes use (default
+// "probe_key_").
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) { o.keyPrefix = prefix }
+}
+
+// WithSentinelPassword sets the password used to authenticate with
+// sentinels.
+func WithSentinelPassword(password string) Option {
+	return func(o *options) { o.sentinelPassword = password }
+}
+
+// WithTimeouts sets the dial, read and write timeouts; zero keeps the
+// go-redis default.
+func WithTimeouts(dial, read, write time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = dial
+		o.readTimeout = read
+		o.writeTimeout = write
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{keyPrefix: defaultKeyPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// dialer returns the go-redis Dialer: with TLS each connection checks the
+// certificate against the host it dials, also when that is an IP address,
+// which go-redis would not send as SNI. Without TLS it returns nil, the
+// go-redis default.
+func (o options) dialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if o.tlsSettings == nil {
+		return nil
+	}
+	c := *o.tlsSettings
+	timeout := o.dialTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		d := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: timeout, KeepAlive: 5 * time.Minute},
+			Config:    c.ClientConfigFor(tlsconfig.ServerName(addr)),
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// clientOptions are the go-redis options for the standalone node at addr.
+func (o options) clientOptions(addr string) *redis.Options {
+	return &redis.Options{
+		Addr:         addr,
+		Username:     o.username,
+		Password:     o.password,
+		DB:           o.db,
+		Dialer:       o.dialer(),
+		DialTimeout:  o.dialTimeout,
+		ReadTimeout:  o.readTimeout,
+		WriteTimeout: o.writeTimeout,
+	}
+}
+
+// clusterOptions are the go-redis options for a cluster; a cluster only has
+// database 0.
+func (o options) clusterOptions(addrs []string) *redis.ClusterOptions {
+	return &redis.ClusterOptions{
+		Addrs:        addrs,
+		Username:     o.username,
+		Password:     o.password,
+		Dialer:       o.dialer(),
+		DialTimeout:  o.dialTimeout,
+		ReadTimeout:  o.readTimeout,
+		WriteTimeout: o.writeTimeout,
+	}
+}
+
+// failoverOptions are the go-redis options for the master or, with
+// replicaOnly, a replica of master behind the sentinels.
+func (o options) failoverOptions(sentinels []string, master string, replicaOnly bool) *redis.FailoverOptions {
+	return &redis.FailoverOptions{
+		MasterName:       master,
+		SentinelAddrs:    sentinels,
+		SentinelPassword: o.sentinelPassword,
+		Username:         o.username,
+		Password:         o.password,
+		DB:               o.db,
+		Dialer:           o.dialer(),
+		DialTimeout:      o.dialTimeout,
+		ReadTimeout:      o.readTimeout,
+		WriteTimeout:     o.writeTimeout,
+		ReplicaOnly:      replicaOnly,
+	}
+}
+
+type ReadProbe struct {
+	Region   string
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      *tls.Config
+	client   *redis.Client
+	// connect builds the client, also when reconnecting.
+	connect func() *redis.Client
+}
+
+// NewReadProbe creates a ReadProbe with a persistent client
+func NewReadProbe(addr string, opts ...Option) *ReadProbe {
+	o := newOptions(opts)
+	p := &ReadProbe{
+		Region:   o.region,
+		Addr:     addr,
+		Username: o.username,
+		Password: o.password,
+		DB:       o.db,
+		TLS:      o.tls,
+	}
+	p.connect = func() *redis.Client { return redis.NewClient(o.clientOptions(addr)) }
+	p.client = p.connect()
+	return p
+}
+
+func (p *ReadProbe) Probe(ctx context.Context) error {
+	_, err := p.client.Ping(ctx).Result()
+	if err != nil {
+		// Try to reconnect once
+		p.client.Close()
+		p.client = p.connect()
+		_, err = p.client.Ping(ctx).Result()
+	}
+	return err
+}
+
+func (p *ReadProbe) Close() {
+	p.client.Close()
+}
+
+func (p *ReadProbe) MetadataString() string {
+	return fmt.Sprintf("Node: %s , Region: %s", p.Addr, p.Region)
+}
+
+func (p *ReadProbe) Operation() string {
+	return "read"
+}
+
+// WriteProbe writes a random value to its key, reads it back, compares it
+// and deletes the key. The key is fixed for the lifetime of the probe and
+// has a TTL, so no keys accumulate even when the delete fails.
+type WriteProbe struct {
+	Region   string
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      *tls.Config
+	Key      string
+	client   *redis.Client
+	connect  func() *redis.Client
+}
+
+// NewWriteProbe creates a WriteProbe with a persistent client
+func NewWriteProbe(addr string, opts ...Option) *WriteProbe {
+	o := newOptions(opts)
+	p := &WriteProbe{
+		Region:   o.region,
+		Addr:     addr,
+		Username: o.username,
+		Password: o.password,
+		DB:       o.db,
+		TLS:      o.tls,
+		Key:      o.keyPrefix + RandString(12),
+	}
+	p.connect = func() *redis.Client { return redis.NewClient(o.clientOptions(addr)) }
+	p.client = p.connect()
+	return p
+}
+
+func (p *WriteProbe) Probe(ctx context.Context) error {
+	if os.Getenv("DEBUG") == "1" {
+		log.Printf("[DEBUG][Redis][%s] Writing key: %s", p.Addr, p.Key)
+	}
+	err := roundTrip(ctx, p.client, p.Addr, p.Key)
+	if err != nil && !errors.Is(err, errMismatch) {
+		// Try to reconnect once
+		p.client.Close()
+		p.client = p.connect()
+		err = roundTrip(ctx, p.client, p.Addr, p.Key)
+	}
+	return err
+}
+
+var errMismatch = errors.New("value mismatch")
+
+// roundTrip sets, gets and deletes key, timing each command as node.
+func roundTrip(ctx context.Context, client redis.Cmdable, node, key string) error {
+	value := RandString(16)
+	if err := timed(ctx, node, "set", func() error {
+		return client.Set(ctx, key, value, 30*time.Second).Err()
+	}); err != nil {
+		return fmt.Errorf("SET %s: %w", key, err)
+	}
+	var got string
+	if err := timed(ctx, node, "get", func() (err error) {
+		got, err = client.Get(ctx, key).Result()
+		return err
+	}); err != nil {
+		return fmt.Errorf("GET %s: %w", key, err)
+	}
+	if got != value {
+		return fmt.Errorf("GET %s returned %q, want %q: %w", key, got, value, errMismatch)
+	}
+	if err := timed(ctx, node, "del", func() error {
+		return client.Del(ctx, key).Err()
+	}); err != nil {
+		return fmt.Errorf("DEL %s: %w", key, err)
+	}
+	return nil
+}
+
+// timed runs a command and records its duration.
+func timed(ctx context.Context, node, command string, f func() error) error {
+	start := time.Now()
+	err := f()
+	commandDuration.Observe(ctx, time.Since(start).Seconds(), node, command)
+	return err
+}
+
+func (p *WriteProbe) Close() {
+	p.client.Close()
+}
+
+func (p *WriteProbe) MetadataString() string {
+	return fmt.Sprintf("Node: %s , Region: %s , Key: %s", p.Addr, p.Region, p.Key)
+}
+
+func (p *WriteProbe) Operation() string {
+	return "write"
+}
@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+var (
+	sentinelQuorum = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_sentinel_quorum_ok",
+		Help: "Whether each sentinel reports enough sentinels to reach the quorum and authorize a failover (SENTINEL CKQUORUM)",
+	}, "sentinel")
+	sentinelMaster = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_redis_sentinel_master_info",
+		Help: "Address of the current master as reported by the sentinels; always 1",
+	}, "master_addr")
+	sentinelFailovers = probe.NewCounterVec(prometheus.CounterOpts{
+		Name: "prober_redis_sentinel_failovers_total",
+		Help: "Master address changes seen between sentinel probes",
+	})
+)
+
+// NewSentinelWriteProbe creates a WriteProbe on the current master of
+// master, as resolved through the sentinels.
+func NewSentinelWriteProbe(sentinels []string, master string, opts ...Option) *WriteProbe {
+	o := newOptions(opts)
+	p := &WriteProbe{
+		Region:   o.region,
+		Addr:     "master " + master,
+		Username: o.username,
+		Password: o.password,
+		DB:       o.db,
+		TLS:      o.tls,
+		Key:      o.keyPrefix + RandString(12),
+	}
+	p.connect = func() *redis.Client { return redis.NewFailoverClient(o.failoverOptions(sentinels, master, false)) }
+	p.client = p.connect()
+	return p
+}
+
+// NewSentinelReadProbe creates a ReadProbe on a replica of master, picked
+// at random on every connection.
+func NewSentinelReadProbe(sentinels []string, master string, opts ...Option) *ReadProbe {
+	o := newOptions(opts)
+	p := &ReadProbe{
+		Region:   o.region,
+		Addr:     "replica of " + master,
+		Username: o.username,
+		Password: o.password,
+		DB:       o.db,
+		TLS:      o.tls,
+	}
+	p.connect = func() *redis.Client { return redis.NewFailoverClient(o.failoverOptions(sentinels, master, true)) }
+	p.client = p.connect()
+	return p
+}
+
+// SentinelProbe asks every sentinel for the master address and checks its
+// quorum. It fails when a sentinel is unreachable, can't reach its quorum,
+// or the sentinels disagree on the master; a changed master is reported as
+// a failover.
+type SentinelProbe struct {
+	Region           string
+	Sentinels        []string
+	MasterName       string
+	SentinelPassword string
+	TLS              *tls.Config
+	clients          []*redis.SentinelClient
+	lastMaster       string
+}
+
+// NewSentinelProbe creates a SentinelProbe with persistent clients.
+func NewSentinelProbe(sentinels []string, master string, opts ...Option) *SentinelProbe {
+	o := newOptions(opts)
+	p := &SentinelProbe{
+		Region:           o.region,
+		Sentinels:        sentinels,
+		MasterName:       master,
+		SentinelPassword: o.sentinelPassword,
+		TLS:              o.tls,
+	}
+	for _, addr := range sentinels {
+		p.clients = append(p.clients, redis.NewSentinelClient(&redis.Options{
+			Addr:         addr,
+			Password:     o.sentinelPassword,
+			Dialer:       o.dialer(),
+			DialTimeout:  o.dialTimeout,
+			ReadTimeout:  o.readTimeout,
+			WriteTimeout: o.writeTimeout,
+		}))
+	}
+	return p
+}
+
+func (p *SentinelProbe) Probe(ctx context.Context) error {
+	var errs []string
+	masters := make(map[string][]string)
+	for i, client := range p.clients {
+		sentinel := p.Sentinels[i]
+		addr, err := client.GetMasterAddrByName(ctx, p.MasterName).Result()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: get-master-addr-by-name: %v", sentinel, err))
+		} else if len(addr) == 2 {
+			master := net.JoinHostPort(addr[0], addr[1])
+			masters[master] = append(masters[master], sentinel)
+		}
+		ok := 1.0
+		if err := client.CkQuorum(ctx, p.MasterName).Err(); err != nil {
+			ok = 0
+			errs = append(errs, fmt.Sprintf("%s: ckquorum: %v", sentinel, err))
+		}
+		sentinelQuorum.Set(ctx, ok, sentinel)
+	}
+
+	sentinelMaster.Reset(ctx, nil)
+	switch len(masters) {
+	case 0:
+	case 1:
+		for master := range masters {
+			sentinelMaster.Set(ctx, 1, master)
+			probe.AddDetail(ctx, "Current master", master)
+			if p.lastMaster != "" && p.lastMaster != master {
+				sentinelFailovers.Inc(ctx)
+				probe.AddDetail(ctx, "Failover", p.lastMaster+" -> "+master)
+			}
+			p.lastMaster = master
+		}
+	default:
+		var views []string
+		for master, sentinels := range masters {
+			sentinelMaster.Set(ctx, 1, master)
+			views = append(views, fmt.Sprintf("%s by %s", master, strings.Join(sentinels, ",")))
+		}
+		errs = append(errs, "sentinels disagree on the master: "+strings.Join(views, "; "))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("sentinel errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p *SentinelProbe) Close() {
+	for _, client := range p.clients {
+		client.Close()
+	}
+}
+
+func (p *SentinelProbe) MetadataString() string {
+	return fmt.Sprintf("Sentinels: %v , Master: %s , Region: %s", p.Sentinels, p.MasterName, p.Region)
+}
+
+func (p *SentinelProbe) Operation() string {
+	return "sentinel"
+}
@@ -0,0 +1,117 @@
+package probe
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClusterBase holds the settings shared by every probe kind. Cluster configs
+// embed it inline so that name, region, duration and labels keep their
+// top-level YAML keys.
+type ClusterBase struct {
+	Name     string            `yaml:"name"`
+	Region   string            `yaml:"region"`
+	Duration DurationString    `yaml:"duration"`
+	Labels   map[string]string `yaml:"labels"`
+}
+
+// Base returns the shared cluster settings.
+func (b ClusterBase) Base() ClusterBase {
+	return b
+}
+
+// Cluster is the config of a single cluster of some probe kind.
+type Cluster interface {
+	Base() ClusterBase
+}
+
+// Validator is implemented by clusters that check their own config. It is
+// called when the config is loaded, so a bad cluster keeps the last good
+// config running.
+type Validator interface {
+	Validate() error
+}
+
+// Factory builds the probers for one cluster of a kind.
+type Factory[C Cluster] func(cluster C) ([]Prober, error)
+
+type kind struct {
+	name   string
+	typ    reflect.Type
+	decode func(node *yaml.Node) (Cluster, error)
+	build  func(cluster Cluster) ([]Prober, error)
+}
+
+var (
+	kindsMu sync.RWMutex
+	kinds   = make(map[string]*kind)
+)
+
+// Register makes a probe kind available under name, both as a `kind:` in the
+// `probes:` list and as a top-level config section. It is meant to be called
+// from the init function of the kind's package and panics if the name is
+// registered twice.
+func Register[C Cluster](name string, factory Factory[C]) {
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	if factory == nil {
+		panic("probe: Register factory is nil for kind " + name)
+	}
+	if _, dup := kinds[name]; dup {
+		panic("probe: Register called twice for kind " + name)
+	}
+	kinds[name] = &kind{
+		name: name,
+		typ:  reflect.TypeOf((*C)(nil)).Elem(),
+		decode: func(node *yaml.Node) (Cluster, error) {
+			var cluster C
+			if err := node.Decode(&cluster); err != nil {
+				return nil, err
+			}
+			return cluster, nil
+		},
+		build: func(cluster Cluster) ([]Prober, error) {
+			c, ok := cluster.(C)
+			if !ok {
+				return nil, fmt.Errorf("probe kind %s: unexpected cluster config type %T", name, cluster)
+			}
+			return factory(c)
+		},
+	}
+}
+
+// Kinds returns the sorted names of the registered probe kinds.
+func Kinds() []string {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// kindOf returns the name of the kind whose cluster config type is that of cluster.
+func kindOf(cluster Cluster) (string, bool) {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	typ := reflect.TypeOf(cluster)
+	for name, k := range kinds {
+		if k.typ == typ {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func lookupKind(name string) (*kind, bool) {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	k, ok := kinds[name]
+	return k, ok
+}
@@ -0,0 +1,65 @@
+package probe
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Target identifies the cluster a probe run belongs to. The ProbeManager
+// puts it in the context passed to Probe, and the metric vectors of this
+// package take their standard and custom label values from it.
+type Target struct {
+	Kind              string
+	Operation         string
+	Name              string
+	SourceRegion      string
+	DestinationRegion string
+	Labels            map[string]string
+}
+
+type runState struct {
+	target  Target
+	mu      sync.Mutex
+	details []string
+}
+
+type runStateKey struct{}
+
+// ContextWithTarget returns a context for a single probe run of target.
+// Probes run by a ProbeManager already get one.
+func ContextWithTarget(ctx context.Context, target Target) context.Context {
+	return context.WithValue(ctx, runStateKey{}, &runState{target: target})
+}
+
+// TargetFromContext returns the target of the probe run in ctx, if any.
+func TargetFromContext(ctx context.Context) (Target, bool) {
+	rs, ok := ctx.Value(runStateKey{}).(*runState)
+	if !ok {
+		return Target{}, false
+	}
+	return rs.target, true
+}
+
+// AddDetail attaches "key: value" to the details of the current probe run,
+// which are reported with its Result. It is a no-op when ctx carries no run.
+func AddDetail(ctx context.Context, key, value string) {
+	rs, ok := ctx.Value(runStateKey{}).(*runState)
+	if !ok {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.details = append(rs.details, key+": "+value)
+}
+
+// runDetails returns the details added during the run in ctx.
+func runDetails(ctx context.Context) string {
+	rs, ok := ctx.Value(runStateKey{}).(*runState)
+	if !ok {
+		return ""
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return strings.Join(rs.details, " , ")
+}
@@ -0,0 +1,55 @@
+package s3
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type S3Tasks struct {
+	Read  bool `yaml:"read"`
+	Write bool `yaml:"write"`
+}
+
+type S3Cluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Endpoint          string               `yaml:"endpoint"`
+	AccessKey         string               `yaml:"accessKey"`
+	SecretKey         string               `yaml:"secretKey"`
+	Bucket            string               `yaml:"bucket"`
+	UseSSL            bool                 `yaml:"useSSL"`
+	Timeout           probe.DurationString `yaml:"timeout"`
+	Tasks             S3Tasks              `yaml:"tasks"`
+	TLS               tlsconfig.Config     `yaml:"tls"`
+}
+
+func (c S3Cluster) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	probe.Register("s3", newProbers)
+}
+
+func newProbers(cluster S3Cluster) ([]probe.Prober, error) {
+	var probers []probe.Prober
+	opts := []Option{
+		WithRegion(cluster.Region),
+		WithCredentials(cluster.AccessKey, cluster.SecretKey),
+		WithSSL(cluster.UseSSL),
+		WithTimeout(cluster.Timeout.ToDuration(time.Second)),
+		WithTLS(cluster.TLS),
+	}
+	if cluster.Tasks.Write {
+		probers = append(probers, NewWriteProbe(cluster.Endpoint, cluster.Bucket, opts...))
+	}
+	if cluster.Tasks.Read {
+		probers = append(probers, NewReadProbe(cluster.Endpoint, cluster.Bucket, opts...))
+	}
+	return probers, nil
+}
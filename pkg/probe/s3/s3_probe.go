@@ -1,13 +1,16 @@
+// Package s3 probes S3-compatible object stores with object reads and writes.
 package s3
 
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
 )
 
 type WriteProbe struct {
@@ -25,21 +29,99 @@ type WriteProbe struct {
 	Bucket    string
 	UseSSL    bool
 	ObjectKey string        // e.g. "probe-test-file" (used for read probe only)
-	Timeout   time.Duration // Timeout in seconds for S3 operations
+	Timeout   time.Duration // Timeout for S3 operations
+	TLS       *tls.Config
 	client    *s3.Client
 }
 
+type options struct {
+	region    string
+	accessKey string
+	secretKey string
+	objectKey string
+	useSSL    bool
+	timeout   time.Duration
+	tls       *tlsconfig.Config
+}
+
+// Option configures the S3 probes.
+type Option func(*options)
+
+// WithRegion sets the bucket region, used for request signing.
+func WithRegion(region string) Option {
+	return func(o *options) { o.region = region }
+}
+
+// WithCredentials sets static access and secret keys.
+func WithCredentials(accessKey, secretKey string) Option {
+	return func(o *options) {
+		o.accessKey = accessKey
+		o.secretKey = secretKey
+	}
+}
+
+// WithObjectKey sets the object read by the ReadProbe (default "probe-test-file").
+func WithObjectKey(objectKey string) Option {
+	return func(o *options) { o.objectKey = objectKey }
+}
+
+// WithSSL marks the endpoint as using TLS.
+func WithSSL(useSSL bool) Option {
+	return func(o *options) { o.useSSL = useSSL }
+}
+
+// WithTimeout sets the timeout for S3 operations (default 1s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithTLS sets the CA bundle, client certificate and other TLS settings for
+// https endpoints when c is enabled.
+func WithTLS(c tlsconfig.Config) Option {
+	return func(o *options) {
+		if c.Enabled {
+			o.tls = &c
+		}
+	}
+}
+
+// tlsConfig returns the TLS config for endpoint, whose certificate is
+// checked against the endpoint host, or nil without TLS settings.
+func (o options) tlsConfig(endpoint string) *tls.Config {
+	if o.tls == nil {
+		return nil
+	}
+	var host string
+	if u, err := url.Parse(endpoint); err == nil {
+		host = u.Hostname()
+	}
+	return o.tls.ClientConfigFor(host)
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		objectKey: "probe-test-file",
+		timeout:   time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // NewWriteProbe creates a WriteProbe with a persistent S3 client
-func NewWriteProbe(endpoint, region, accessKey, secretKey, bucket, objectKey string, useSSL bool, timeout time.Duration) *WriteProbe {
+func NewWriteProbe(endpoint, bucket string, opts ...Option) *WriteProbe {
+	o := newOptions(opts)
 	return &WriteProbe{
 		Endpoint:  endpoint,
-		Region:    region,
-		AccessKey: accessKey,
-		SecretKey: secretKey,
+		Region:    o.region,
+		AccessKey: o.accessKey,
+		SecretKey: o.secretKey,
 		Bucket:    bucket,
-		UseSSL:    useSSL,
-		ObjectKey: objectKey,
-		Timeout:   timeout,
+		UseSSL:    o.useSSL,
+		ObjectKey: o.objectKey,
+		Timeout:   o.timeout,
+		TLS:       o.tlsConfig(endpoint),
 	}
 }
 
@@ -61,9 +143,7 @@ func (p *WriteProbe) Probe(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		httpClient := &http.Client{
-			Timeout: p.Timeout,
-		}
+		httpClient := newHTTPClient(p.Timeout, p.TLS)
 		p.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
 			o.RetryMaxAttempts = 1
 			o.UsePathStyle = true
@@ -83,6 +163,19 @@ func (p *WriteProbe) Probe(ctx context.Context) error {
 	return err
 }
 
+// newHTTPClient returns the client for S3 requests, with tlsConfig for https
+// endpoints if not nil.
+func newHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if tlsConfig != nil {
+		client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+	}
+	return client
+}
+
 // RandString generates a random alphanumeric string of given length
 func RandString(n int) string {
 	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
@@ -102,21 +195,24 @@ type ReadProbe struct {
 	Bucket    string
 	UseSSL    bool
 	ObjectKey string        // e.g. "probe-test-file"
-	Timeout   time.Duration // Timeout in seconds for S3 operations
+	Timeout   time.Duration // Timeout for S3 operations
+	TLS       *tls.Config
 	client    *s3.Client
 }
 
 // NewReadProbe creates a ReadProbe with a persistent S3 client
-func NewReadProbe(endpoint, region, accessKey, secretKey, bucket, objectKey string, useSSL bool, timeout time.Duration) *ReadProbe {
+func NewReadProbe(endpoint, bucket string, opts ...Option) *ReadProbe {
+	o := newOptions(opts)
 	return &ReadProbe{
 		Endpoint:  endpoint,
-		Region:    region,
-		AccessKey: accessKey,
-		SecretKey: secretKey,
+		Region:    o.region,
+		AccessKey: o.accessKey,
+		SecretKey: o.secretKey,
 		Bucket:    bucket,
-		UseSSL:    useSSL,
-		ObjectKey: objectKey,
-		Timeout:   timeout,
+		UseSSL:    o.useSSL,
+		ObjectKey: o.objectKey,
+		Timeout:   o.timeout,
+		TLS:       o.tlsConfig(endpoint),
 	}
 }
 
@@ -134,9 +230,7 @@ func (p *ReadProbe) Probe(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		httpClient := &http.Client{
-			Timeout: time.Duration(p.Timeout) * time.Second,
-		}
+		httpClient := newHTTPClient(p.Timeout, p.TLS)
 		p.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
 			o.UsePathStyle = true
 			o.HTTPClient = httpClient
@@ -161,9 +255,7 @@ func (p *ReadProbe) Probe(ctx context.Context) error {
 		if cfgErr != nil {
 			return err // return original error if recovery fails
 		}
-		httpClient := &http.Client{
-			Timeout: time.Duration(p.Timeout) * time.Second,
-		}
+		httpClient := newHTTPClient(p.Timeout, p.TLS)
 		p.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
 			o.UsePathStyle = true
 			o.HTTPClient = httpClient
@@ -185,6 +277,14 @@ func (p *ReadProbe) MetadataString() string {
 	return fmt.Sprintf("Endpoint: %s , Bucket: %s , Region: %s", p.Endpoint, p.Bucket, p.Region)
 }
 
+func (p *ReadProbe) Operation() string {
+	return "read"
+}
+
 func (p *WriteProbe) MetadataString() string {
 	return fmt.Sprintf("Endpoint: %s , Bucket: %s , Region: %s", p.Endpoint, p.Bucket, p.Region)
 }
+
+func (p *WriteProbe) Operation() string {
+	return "write"
+}
@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe/identity"
+)
+
+// IdentityConfig selects how the prober finds its own region, node name and
+// node IP. Providers are tried in order; the first to set a field wins.
+type IdentityConfig struct {
+	// Providers is a list of env, aws, gce and kubernetes (default [env]).
+	Providers     []string       `yaml:"providers"`
+	Timeout       DurationString `yaml:"timeout"` // per provider, default 2s
+	AWSEndpoint   string         `yaml:"awsEndpoint"`
+	GCEEndpoint   string         `yaml:"gceEndpoint"`
+	KubernetesDir string         `yaml:"kubernetesDir"`
+}
+
+func (ic IdentityConfig) providers() ([]identity.Provider, error) {
+	names := ic.Providers
+	if len(names) == 0 {
+		names = []string{"env"}
+	}
+	var providers []identity.Provider
+	for _, name := range names {
+		switch name {
+		case "env":
+			providers = append(providers, identity.Env{})
+		case "aws":
+			providers = append(providers, &identity.AWS{Endpoint: ic.AWSEndpoint})
+		case "gce":
+			providers = append(providers, &identity.GCE{Endpoint: ic.GCEEndpoint})
+		case "kubernetes":
+			providers = append(providers, &identity.Kubernetes{Dir: ic.KubernetesDir})
+		default:
+			return nil, fmt.Errorf("unknown identity provider %q", name)
+		}
+	}
+	return providers, nil
+}
+
+var (
+	sourceMu       sync.RWMutex
+	source         = identity.Default
+	sourceResolved bool
+)
+
+// SourceIdentity returns the identity of the node the prober runs on.
+func SourceIdentity() identity.Identity {
+	sourceMu.RLock()
+	defer sourceMu.RUnlock()
+	return source
+}
+
+// SetIdentity sets the prober's identity, overriding the identity providers
+// of the config.
+func SetIdentity(id identity.Identity) {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	source = id
+	sourceResolved = true
+}
+
+// resolveIdentity resolves the identity with the providers of ic, unless it
+// has already been resolved or set: the identity doesn't change while the
+// prober runs.
+func resolveIdentity(ctx context.Context, ic IdentityConfig) {
+	sourceMu.RLock()
+	resolved := sourceResolved
+	sourceMu.RUnlock()
+	if resolved {
+		return
+	}
+	providers, err := ic.providers()
+	if err != nil {
+		// Validate already rejected this config.
+		log.Printf("[Identity] %v", err)
+		return
+	}
+	id := identity.Resolve(ctx, ic.Timeout.ToDuration(2*time.Second), providers...)
+	log.Printf("[Identity] region=%s node=%s ip=%s (providers: %s)", id.Region, id.NodeName, id.NodeIP, id.Provider)
+	SetIdentity(id)
+}
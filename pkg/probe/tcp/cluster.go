@@ -0,0 +1,75 @@
+package tcp
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlscheck"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type TCPCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Addresses         []string             `yaml:"addresses"`
+	Timeout           probe.DurationString `yaml:"timeout"`
+	TLS               tlsconfig.Config     `yaml:"tls"`
+	TLSCheck          tlscheck.Config      `yaml:"tlsCheck"`
+	QueryResponse     []QueryResponse      `yaml:"queryResponse"`
+	Quorum            string               `yaml:"quorum"`
+	Parallelism       int                  `yaml:"parallelism"`
+	ResolveAll        bool                 `yaml:"resolveAll"`
+	Resolver          string               `yaml:"resolver"`
+}
+
+func init() {
+	probe.Register("tcp", newProbers)
+}
+
+func (c TCPCluster) Validate() error {
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if err := c.TLSCheck.Validate(); err != nil {
+		return fmt.Errorf("tlsCheck: %w", err)
+	}
+	if _, err := compileScript(c.QueryResponse, 0); err != nil {
+		return err
+	}
+	n := len(c.Addresses)
+	if c.ResolveAll {
+		// Each address may resolve to several; the count is checked when
+		// probing.
+		n = math.MaxInt32
+	}
+	if _, err := quorumSize(c.Quorum, n); err != nil {
+		return err
+	}
+	if c.Resolver != "" {
+		if _, err := resolverAddress(c.Resolver); err != nil {
+			return err
+		}
+	}
+	if c.Parallelism < 0 {
+		return fmt.Errorf("parallelism must not be negative")
+	}
+	return nil
+}
+
+func newProbers(cluster TCPCluster) ([]probe.Prober, error) {
+	opts := []Option{
+		WithTimeout(cluster.Timeout.ToDuration(2 * time.Second)),
+		WithRegion(cluster.Region),
+		WithQueryResponse(cluster.QueryResponse),
+		WithQuorum(cluster.Quorum),
+		WithParallelism(cluster.Parallelism),
+		WithResolveAll(cluster.ResolveAll),
+		WithResolver(cluster.Resolver),
+	}
+	if cluster.TLS.Enabled || hasStartTLS(cluster.QueryResponse) {
+		opts = append(opts, WithTLS(cluster.TLS, cluster.TLSCheck))
+	}
+	p := NewTCPProbe(cluster.Addresses, opts...)
+	return []probe.Prober{p}, nil
+}
@@ -0,0 +1,31 @@
+package tcp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Quorum rules: how many addresses must succeed for the probe to pass.
+// Besides these, a quorum can be a number of addresses.
+const (
+	QuorumAll = "all"
+	QuorumAny = "any"
+)
+
+// quorumSize returns how many of n addresses must succeed under quorum.
+func quorumSize(quorum string, n int) (int, error) {
+	switch quorum {
+	case "", QuorumAll:
+		return n, nil
+	case QuorumAny:
+		return min(1, n), nil
+	}
+	k, err := strconv.Atoi(quorum)
+	if err != nil || k < 1 {
+		return 0, fmt.Errorf("invalid quorum %q, want all, any or a positive number", quorum)
+	}
+	if k > n {
+		return 0, fmt.Errorf("quorum %d exceeds the %d addresses", k, n)
+	}
+	return k, nil
+}
@@ -0,0 +1,34 @@
+package tcp
+
+import "testing"
+
+func TestQuorumSize(t *testing.T) {
+	tests := []struct {
+		quorum  string
+		n       int
+		want    int
+		wantErr bool
+	}{
+		{quorum: "", n: 3, want: 3},
+		{quorum: QuorumAll, n: 3, want: 3},
+		{quorum: QuorumAll, n: 0, want: 0},
+		{quorum: QuorumAny, n: 3, want: 1},
+		{quorum: QuorumAny, n: 0, want: 0},
+		{quorum: "2", n: 3, want: 2},
+		{quorum: "3", n: 3, want: 3},
+		{quorum: "4", n: 3, wantErr: true},
+		{quorum: "0", n: 3, wantErr: true},
+		{quorum: "-1", n: 3, wantErr: true},
+		{quorum: "most", n: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := quorumSize(tt.quorum, tt.n)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("quorumSize(%q, %d) error = %v, wantErr %v", tt.quorum, tt.n, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("quorumSize(%q, %d) = %d, want %d", tt.quorum, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+var dnsDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "prober_tcp_dns_duration_seconds",
+	Help:    "Time to resolve the hostname of a TCP address",
+	Buckets: prometheus.DefBuckets,
+}, "host")
+
+// target is one endpoint the probe dials: a configured address, or with
+// ResolveAll one IP of it.
+type target struct {
+	// address names the target in metrics and errors.
+	address string
+	// host is the hostname of the configured address, for TLS.
+	host string
+	// dial are the IP addresses to try in order, until one connects.
+	dial []string
+	// err is set when the address could not be resolved.
+	err error
+}
+
+// resolverAddress adds the default DNS port to a resolver without one.
+func resolverAddress(resolver string) (string, error) {
+	if net.ParseIP(resolver) != nil {
+		return net.JoinHostPort(resolver, "53"), nil
+	}
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		return "", fmt.Errorf("invalid resolver %q: %v", resolver, err)
+	}
+	return resolver, nil
+}
+
+// newResolver returns a resolver querying addr over UDP (TCP for truncated
+// answers), or the system resolver for "".
+func newResolver(addr string, timeout time.Duration) (*net.Resolver, error) {
+	if addr == "" {
+		return net.DefaultResolver, nil
+	}
+	addr, err := resolverAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}, nil
+}
+
+// targets resolves the hostnames of the addresses concurrently.
+func (p *TCPProbe) targets(ctx context.Context, parallelism int) []target {
+	resolved := make([][]target, len(p.Addresses))
+	forEach(len(p.Addresses), parallelism, func(i int) {
+		resolved[i] = p.resolve(ctx, p.Addresses[i])
+	})
+	var targets []target
+	for _, t := range resolved {
+		targets = append(targets, t...)
+	}
+	return targets
+}
+
+func (p *TCPProbe) resolve(ctx context.Context, addr string) []target {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return []target{{address: addr, err: fmt.Errorf("%s: %v", addr, err)}}
+	}
+	if net.ParseIP(host) != nil {
+		return []target{{address: addr, host: host, dial: []string{addr}}}
+	}
+	start := time.Now()
+	ips, err := p.resolver.LookupIPAddr(ctx, host)
+	dnsDuration.Observe(ctx, time.Since(start).Seconds(), host)
+	if err == nil && len(ips) == 0 {
+		err = fmt.Errorf("no addresses")
+	}
+	if err != nil {
+		return []target{{address: addr, host: host, err: fmt.Errorf("%s: resolve error: %v", addr, err)}}
+	}
+	dial := make([]string, len(ips))
+	for i, ip := range ips {
+		dial[i] = net.JoinHostPort(ip.String(), port)
+	}
+	if !p.ResolveAll {
+		return []target{{address: addr, host: host, dial: dial}}
+	}
+	targets := make([]target, len(dial))
+	for i, d := range dial {
+		targets[i] = target{address: d, host: host, dial: []string{d}}
+	}
+	return targets
+}
+
+// dialTarget connects to the first of the target's IPs that accepts.
+func (p *TCPProbe) dialTarget(ctx context.Context, t *target) (net.Conn, error) {
+	var err error
+	for _, addr := range t.dial {
+		var conn net.Conn
+		if conn, err = p.dialer.DialContext(ctx, "tcp", addr); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("%s: dial error: %v", t.address, err)
+}
+
+// forEach calls f for 0 to n-1, at most parallelism at a time.
+func forEach(n, parallelism int, f func(i int)) {
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,121 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+// QueryResponse is one step of a send/expect dialogue. Each step first
+// waits for a line matching Expect, then writes Send, then upgrades the
+// connection to TLS if StartTLS is set; any of the three may be left out.
+type QueryResponse struct {
+	// Expect is a regex matched against each line the server sends; lines
+	// that don't match are skipped.
+	Expect string `yaml:"expect"`
+	// Send is written as is, so line protocols need an explicit "\r\n".
+	Send     string `yaml:"send"`
+	StartTLS bool   `yaml:"starttls"`
+	// Timeout bounds the step (default: the probe timeout).
+	Timeout probe.DurationString `yaml:"timeout"`
+}
+
+type compiledQuery struct {
+	QueryResponse
+	expect  *regexp.Regexp
+	timeout time.Duration
+}
+
+// compileScript checks the steps and compiles their regexes.
+func compileScript(script []QueryResponse, defaultTimeout time.Duration) ([]compiledQuery, error) {
+	compiled := make([]compiledQuery, 0, len(script))
+	for i, qr := range script {
+		c := compiledQuery{QueryResponse: qr, timeout: qr.Timeout.ToDuration(defaultTimeout)}
+		if qr.Expect == "" && qr.Send == "" && !qr.StartTLS {
+			return nil, fmt.Errorf("queryResponse[%d]: one of expect, send and starttls is required", i)
+		}
+		if qr.Timeout != "" {
+			if _, err := time.ParseDuration(string(qr.Timeout)); err != nil {
+				return nil, fmt.Errorf("queryResponse[%d]: timeout: %w", i, err)
+			}
+		}
+		if qr.Expect != "" {
+			re, err := regexp.Compile(qr.Expect)
+			if err != nil {
+				return nil, fmt.Errorf("queryResponse[%d]: expect: %w", i, err)
+			}
+			c.expect = re
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// hasStartTLS reports whether the script upgrades to TLS, in which case the
+// TLS settings apply to that upgrade rather than to the connection itself.
+func hasStartTLS(script []QueryResponse) bool {
+	for _, qr := range script {
+		if qr.StartTLS {
+			return true
+		}
+	}
+	return false
+}
+
+// runScript runs the dialogue on conn.
+func (p *TCPProbe) runScript(ctx context.Context, conn net.Conn, t *target) error {
+	r := bufio.NewReader(conn)
+	for i, qr := range p.script {
+		if err := conn.SetDeadline(time.Now().Add(qr.timeout)); err != nil {
+			return fmt.Errorf("%s: set deadline error: %v", t.address, err)
+		}
+		if qr.expect != nil {
+			if err := expect(r, qr.expect); err != nil {
+				return fmt.Errorf("%s: queryResponse[%d]: expect %q: %v", t.address, i, qr.Expect, err)
+			}
+		}
+		if qr.Send != "" {
+			if _, err := io.WriteString(conn, qr.Send); err != nil {
+				return fmt.Errorf("%s: queryResponse[%d]: write error: %v", t.address, i, err)
+			}
+		}
+		if qr.StartTLS {
+			tlsConn, err := p.handshake(ctx, conn, t)
+			if err != nil {
+				return fmt.Errorf("queryResponse[%d]: starttls: %w", i, err)
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+		}
+	}
+	return nil
+}
+
+// expect reads lines until one matches re.
+func expect(r *bufio.Reader, re *regexp.Regexp) error {
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && re.Match(trimEOL(line)) {
+			return nil
+		}
+		if err == io.EOF {
+			return fmt.Errorf("connection closed without a match")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func trimEOL(line []byte) []byte {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	return line
+}
@@ -0,0 +1,120 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  []QueryResponse
+		want    []time.Duration // step timeouts
+		wantErr string
+	}{
+		{name: "empty script", want: []time.Duration{}},
+		{
+			name:   "steps with and without timeout",
+			script: []QueryResponse{{Expect: "^220 "}, {Send: "EHLO prober\r\n", Timeout: "500ms"}, {StartTLS: true}},
+			want:   []time.Duration{time.Second, 500 * time.Millisecond, time.Second},
+		},
+		{name: "empty step", script: []QueryResponse{{Expect: "^220 "}, {}}, wantErr: "queryResponse[1]: one of expect, send and starttls is required"},
+		{name: "bad expect", script: []QueryResponse{{Expect: "("}}, wantErr: "queryResponse[0]: expect"},
+		{name: "bad timeout", script: []QueryResponse{{Send: "PING\r\n", Timeout: "soon"}}, wantErr: "queryResponse[0]: timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileScript(tt.script, time.Second)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("compileScript error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compileScript: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("compileScript returned %d steps, want %d", len(got), len(tt.want))
+			}
+			for i, c := range got {
+				if c.timeout != tt.want[i] {
+					t.Errorf("step %d timeout = %v, want %v", i, c.timeout, tt.want[i])
+				}
+				if (c.expect != nil) != (c.Expect != "") {
+					t.Errorf("step %d: expect compiled = %v, want %v", i, c.expect != nil, c.Expect != "")
+				}
+			}
+		})
+	}
+}
+
+func TestHasStartTLS(t *testing.T) {
+	tests := []struct {
+		script []QueryResponse
+		want   bool
+	}{
+		{script: nil, want: false},
+		{script: []QueryResponse{{Expect: "^\\+OK"}, {Send: "QUIT\r\n"}}, want: false},
+		{script: []QueryResponse{{Send: "STARTTLS\r\n"}, {Expect: "^220", StartTLS: true}}, want: true},
+	}
+	for _, tt := range tests {
+		if got := hasStartTLS(tt.script); got != tt.want {
+			t.Errorf("hasStartTLS(%+v) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestExpect(t *testing.T) {
+	tests := []struct {
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{input: "220 mail.example ESMTP\r\n", expect: "^220 "},
+		{input: "220-first\r\n220 last\r\n", expect: "^220 last$"},
+		{input: "STAT pid 1\r\nEND", expect: "^END$"},
+		{input: "-ERR\r\n", expect: "^\\+OK", wantErr: true},
+		{input: "", expect: ".", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := expect(bufio.NewReader(strings.NewReader(tt.input)), regexp.MustCompile(tt.expect))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expect(%q, %q) error = %v, wantErr %v", tt.input, tt.expect, err, tt.wantErr)
+		}
+	}
+}
+
+// TestStartTLSWithoutTLSSettings runs a STARTTLS step on a probe without
+// WithTLS: the handshake uses the defaults and fails against a server that
+// doesn't speak TLS, rather than panicking.
+func TestStartTLSWithoutTLSSettings(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("220 ready\r\n"))
+		bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("220 go ahead\r\nnot a TLS record\r\n"))
+	}()
+	p := NewTCPProbe([]string{l.Addr().String()}, WithTimeout(2*time.Second), WithQueryResponse([]QueryResponse{
+		{Expect: "^220 ready", Send: "STARTTLS\r\n"},
+		{Expect: "^220 go ahead", StartTLS: true},
+	}))
+	err = p.Probe(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "starttls") {
+		t.Errorf("Probe error = %v, want a starttls error", err)
+	}
+}
@@ -0,0 +1,273 @@
+// Package tcp probes that TCP addresses accept connections.
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlscheck"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+type TCPProbe struct {
+	Addresses []string
+	Timeout   time.Duration
+	Region    string
+	// With TLS enabled the probe completes a TLS handshake on every
+	// connection and applies TLSCheck to it.
+	TLS      tlsconfig.Config
+	TLSCheck tlscheck.Config
+	// QueryResponse is a send/expect dialogue run on every connection.
+	// When it has a StartTLS step, TLS is used for that upgrade instead of
+	// a handshake on connect.
+	QueryResponse []QueryResponse
+	// Quorum is QuorumAll (default), QuorumAny or a number of addresses
+	// that must succeed.
+	Quorum string
+	// Parallelism bounds the concurrent lookups and dials (default 10).
+	Parallelism int
+	// ResolveAll probes every A and AAAA record of a hostname as an address
+	// of its own; otherwise the records are tried in order until one
+	// connects.
+	ResolveAll bool
+	// Resolver is the host:port of the DNS server for hostnames (default:
+	// the system resolver).
+	Resolver string
+	resolver *net.Resolver
+	dialer   *net.Dialer
+	script   []compiledQuery
+	// addresses are the addresses of the last run, whose series are
+	// removed once they are no longer probed.
+	addresses map[string]bool
+	// No connection reuse; stateless
+}
+
+const defaultParallelism = 10
+
+var (
+	addressUp = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_tcp_address_up",
+		Help: "Whether the last probe of each TCP address succeeded",
+	}, "address")
+	addressDuration = probe.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prober_tcp_address_duration_seconds",
+		Help:    "Duration of the probe of each TCP address: dial, TLS and dialogue",
+		Buckets: prometheus.DefBuckets,
+	}, "address")
+)
+
+// Option configures a TCPProbe.
+type Option func(*TCPProbe)
+
+// WithTimeout sets the dial and liveness timeout per address (default 2s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *TCPProbe) { p.Timeout = timeout }
+}
+
+// WithRegion sets the region of the probed addresses.
+func WithRegion(region string) Option {
+	return func(p *TCPProbe) { p.Region = region }
+}
+
+// WithTLS completes a TLS handshake with c after connecting, exports the
+// certificate expiry and applies check.
+func WithTLS(c tlsconfig.Config, check tlscheck.Config) Option {
+	return func(p *TCPProbe) {
+		p.TLS = c
+		p.TLS.Enabled = true
+		p.TLSCheck = check
+	}
+}
+
+// WithQueryResponse runs the send/expect dialogue on every connection.
+func WithQueryResponse(script []QueryResponse) Option {
+	return func(p *TCPProbe) { p.QueryResponse = script }
+}
+
+// WithQuorum sets how many addresses must succeed: QuorumAll, QuorumAny or
+// a number.
+func WithQuorum(quorum string) Option {
+	return func(p *TCPProbe) { p.Quorum = quorum }
+}
+
+// WithParallelism bounds the number of concurrent dials.
+func WithParallelism(n int) Option {
+	return func(p *TCPProbe) { p.Parallelism = n }
+}
+
+// WithResolveAll probes every IP address of a hostname on its own.
+func WithResolveAll(resolveAll bool) Option {
+	return func(p *TCPProbe) { p.ResolveAll = resolveAll }
+}
+
+// WithResolver resolves hostnames with the DNS server at addr.
+func WithResolver(addr string) Option {
+	return func(p *TCPProbe) { p.Resolver = addr }
+}
+
+// NewTCPProbe creates a TCPProbe that dials every address.
+func NewTCPProbe(addresses []string, opts ...Option) *TCPProbe {
+	p := &TCPProbe{
+		Addresses: addresses,
+		Timeout:   2 * time.Second,
+		Region:    "",
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Probe implements the Prober interface. Hostnames are resolved and the
+// addresses dialed concurrently, at most Parallelism at a time, and the
+// probe passes when the quorum of them succeeds.
+func (p *TCPProbe) Probe(ctx context.Context) error {
+	if err := p.prepare(); err != nil {
+		return err
+	}
+	parallelism := p.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultParallelism
+	}
+	targets := p.targets(ctx, parallelism)
+	p.dropStaleAddresses(ctx, targets)
+	need, err := quorumSize(p.Quorum, len(targets))
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(targets))
+	forEach(len(targets), parallelism, func(i int) {
+		t := &targets[i]
+		start := time.Now()
+		errs[i] = t.err
+		if errs[i] == nil {
+			errs[i] = p.probeTarget(ctx, t)
+		}
+		addressDuration.Observe(ctx, time.Since(start).Seconds(), t.address)
+		up := 0.0
+		if errs[i] == nil {
+			up = 1
+		}
+		addressUp.Set(ctx, up, t.address)
+	})
+
+	var failed []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	ok := len(targets) - len(failed)
+	probe.AddDetail(ctx, "Up", fmt.Sprintf("%d/%d", ok, len(targets)))
+	if ok < need {
+		return fmt.Errorf("tcp probe errors: %d of %d addresses up, need %d: %s", ok, len(targets), need, strings.Join(failed, "; "))
+	}
+	if len(failed) > 0 {
+		probe.AddDetail(ctx, "Failed", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// dropStaleAddresses removes the per-address series of addresses the last
+// run probed but this one doesn't, e.g. after a DNS rotation with
+// ResolveAll.
+func (p *TCPProbe) dropStaleAddresses(ctx context.Context, targets []target) {
+	current := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		current[t.address] = true
+	}
+	for address := range p.addresses {
+		if !current[address] {
+			addressUp.Reset(ctx, prometheus.Labels{"address": address})
+			addressDuration.Reset(ctx, prometheus.Labels{"address": address})
+		}
+	}
+	p.addresses = current
+}
+
+// prepare builds the state shared by the concurrent dials.
+func (p *TCPProbe) prepare() error {
+	if p.script == nil && len(p.QueryResponse) > 0 {
+		script, err := compileScript(p.QueryResponse, p.Timeout)
+		if err != nil {
+			return err
+		}
+		p.script = script
+	}
+	if p.resolver == nil {
+		resolver, err := newResolver(p.Resolver, p.Timeout)
+		if err != nil {
+			return err
+		}
+		p.resolver = resolver
+		p.dialer = &net.Dialer{Timeout: p.Timeout}
+	}
+	return nil
+}
+
+func (p *TCPProbe) probeTarget(ctx context.Context, t *target) error {
+	conn, err := p.dialTarget(ctx, t)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Set a deadline for liveness check
+	if err := conn.SetDeadline(time.Now().Add(p.Timeout)); err != nil {
+		return fmt.Errorf("%s: set deadline error: %v", t.address, err)
+	}
+	if p.TLS.Enabled && !hasStartTLS(p.QueryResponse) {
+		tlsConn, err := p.handshake(ctx, conn, t)
+		if err != nil {
+			return err
+		}
+		conn = tlsConn
+	}
+	if len(p.QueryResponse) > 0 {
+		return p.runScript(ctx, conn, t)
+	}
+	if !p.TLS.Enabled {
+		if _, err := conn.Write([]byte{}); err != nil {
+			return fmt.Errorf("%s: write error: %v", t.address, err)
+		}
+	}
+	return nil
+}
+
+// handshake completes a TLS handshake on conn and checks the result. Without
+// a configured server name the expected hostname is used, else the host of
+// the configured address. A STARTTLS step without TLS settings uses the
+// defaults.
+func (p *TCPProbe) handshake(ctx context.Context, conn net.Conn, t *target) (*tls.Conn, error) {
+	serverName := p.TLSCheck.Hostname
+	if serverName == "" {
+		serverName = t.host
+	}
+	tlsConn := tls.Client(conn, p.TLS.ClientConfigFor(serverName))
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("%s: TLS handshake error: %v", t.address, err)
+	}
+	state := tlsConn.ConnectionState()
+	if err := p.TLSCheck.Check(ctx, t.address, &state); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (p *TCPProbe) Close() {}
+
+func (p *TCPProbe) MetadataString() string {
+	return fmt.Sprintf("addresses: %v , region: %s , tls: %v , queryResponse steps: %d , quorum: %s", p.Addresses, p.Region, p.TLS.Enabled, len(p.QueryResponse), p.quorumString())
+}
+
+func (p *TCPProbe) quorumString() string {
+	if p.Quorum == "" {
+		return QuorumAll
+	}
+	return p.Quorum
+}
@@ -0,0 +1,107 @@
+// Package tlscheck inspects the certificates and parameters of TLS
+// connections made by probes: certificate expiry, the negotiated version and
+// cipher, and the expected hostname.
+package tlscheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+	"github.com/yourorg/prober/pkg/probe/tlsconfig"
+)
+
+var (
+	certNotAfter = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_tls_cert_not_after_seconds",
+		Help: "NotAfter of the leaf certificate and the earliest NotAfter of the rest of the chain, as a Unix timestamp",
+	}, "server", "cert")
+	tlsInfo = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_tls_info",
+		Help: "Negotiated TLS version and cipher suite; always 1",
+	}, "server", "tls_version", "cipher")
+)
+
+// Config is the `tlsCheck:` block of a cluster.
+type Config struct {
+	// ExpiryWindow fails the probe when a certificate of the chain expires
+	// within it, e.g. "336h".
+	ExpiryWindow probe.DurationString `yaml:"expiryWindow"`
+	// Hostname must be covered by the leaf certificate.
+	Hostname string `yaml:"hostname"`
+	// MinVersion is the lowest acceptable TLS version: "1.0" to "1.3".
+	MinVersion string `yaml:"minVersion"`
+}
+
+// Validate checks the config.
+func (c Config) Validate() error {
+	if c.ExpiryWindow != "" {
+		if _, err := time.ParseDuration(string(c.ExpiryWindow)); err != nil {
+			return fmt.Errorf("expiryWindow: %w", err)
+		}
+	}
+	if _, err := tlsconfig.ParseVersion(c.MinVersion); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Check exports the certificate expiry and negotiated parameters of the
+// connection to server (host:port) for the run in ctx, adds them to the run
+// details, and applies the configured checks.
+func (c Config) Check(ctx context.Context, server string, state *tls.ConnectionState) error {
+	if state == nil {
+		return fmt.Errorf("%s: no TLS connection", server)
+	}
+	chain := state.PeerCertificates
+	if len(state.VerifiedChains) > 0 {
+		chain = state.VerifiedChains[0]
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("%s: no peer certificates", server)
+	}
+	leaf := chain[0]
+	version := tls.VersionName(state.Version)
+	cipher := tls.CipherSuiteName(state.CipherSuite)
+
+	certNotAfter.Set(ctx, float64(leaf.NotAfter.Unix()), server, "leaf")
+	earliest := leaf
+	if len(chain) > 1 {
+		first := chain[1]
+		for _, cert := range chain[2:] {
+			if cert.NotAfter.Before(first.NotAfter) {
+				first = cert
+			}
+		}
+		certNotAfter.Set(ctx, float64(first.NotAfter.Unix()), server, "chain")
+		if first.NotAfter.Before(earliest.NotAfter) {
+			earliest = first
+		}
+	}
+	tlsInfo.Reset(ctx, prometheus.Labels{"server": server})
+	tlsInfo.Set(ctx, 1, server, version, cipher)
+	probe.AddDetail(ctx, "TLS", fmt.Sprintf("%s %s %s, leaf expires %s", server, version, cipher, leaf.NotAfter.UTC().Format(time.RFC3339)))
+
+	if window := c.ExpiryWindow.ToDuration(0); window > 0 {
+		if left := time.Until(earliest.NotAfter); left < window {
+			return fmt.Errorf("%s: certificate %q expires in %s (at %s), within the %s window",
+				server, earliest.Subject.CommonName, left.Round(time.Minute), earliest.NotAfter.UTC().Format(time.RFC3339), window)
+		}
+	}
+	if c.Hostname != "" {
+		if err := leaf.VerifyHostname(c.Hostname); err != nil {
+			return fmt.Errorf("%s: %w", server, err)
+		}
+	}
+	minVersion, err := tlsconfig.ParseVersion(c.MinVersion)
+	if err != nil {
+		return err
+	}
+	if state.Version < minVersion {
+		return fmt.Errorf("%s: negotiated %s, want at least %s", server, version, tls.VersionName(minVersion))
+	}
+	return nil
+}
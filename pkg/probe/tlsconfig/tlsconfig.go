@@ -0,0 +1,250 @@
+// Package tlsconfig is the `tls:` block shared by the cluster types: a CA
+// bundle, a client certificate for mutual TLS, the server name and the
+// minimum version. Certificate files are re-read when they change.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the `tls:` block of a cluster. In YAML it is either a mapping,
+// which enables TLS, or a boolean enabling TLS with the defaults.
+type Config struct {
+	// Enabled is set by both YAML forms; set it when building a Config in
+	// code. HTTP probes ignore it and use TLS for https URLs.
+	Enabled bool `yaml:"-"`
+	// CAFile is a PEM bundle to verify the server with instead of the
+	// system roots.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile are the PEM client certificate and key.
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	// MinVersion is "1.0" to "1.3".
+	MinVersion string `yaml:"minVersion"`
+}
+
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = Config{}
+		return node.Decode(&c.Enabled)
+	}
+	type plain Config
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*c = Config(p)
+	c.Enabled = true
+	return nil
+}
+
+// Validate checks the settings and that the files can be loaded.
+func (c Config) Validate() error {
+	if _, err := ParseVersion(c.MinVersion); err != nil {
+		return err
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("certFile and keyFile must be set together")
+	}
+	f := &files{c: c}
+	if c.CAFile != "" {
+		if _, err := f.roots(); err != nil {
+			return err
+		}
+	}
+	if c.CertFile != "" {
+		if _, err := f.clientCert(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseVersion parses "1.0" to "1.3"; "" is 0.
+func ParseVersion(v string) (uint16, error) {
+	switch v {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("invalid TLS version %q, want 1.0, 1.1, 1.2 or 1.3", v)
+}
+
+// ClientConfig returns a tls.Config for c. The CA bundle and client
+// certificate are read on the first handshake and re-read on later
+// handshakes when their files change, so long-lived clients pick up
+// rotated certificates on their next connection. An invalid MinVersion is
+// ignored; Validate reports it.
+//
+// With a CA bundle the certificate is checked against ServerName, or else
+// the SNI name of the connection. An IP address is not sent as SNI, so
+// connections by IP address fail unless they use ClientConfigFor.
+func (c Config) ClientConfig() *tls.Config {
+	return c.ClientConfigFor("")
+}
+
+// ClientConfigFor is ClientConfig for a connection to serverName, a host
+// name or IP address, which is used when c has no ServerName.
+func (c Config) ClientConfigFor(serverName string) *tls.Config {
+	f := filesFor(c)
+	minVersion, _ := ParseVersion(c.MinVersion)
+	if c.ServerName != "" {
+		serverName = c.ServerName
+	}
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         minVersion,
+	}
+	if c.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return f.clientCert()
+		}
+	}
+	if c.CAFile != "" && !c.InsecureSkipVerify {
+		// The roots can't change under a tls.Config, so the chain is
+		// verified here against the current bundle instead.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			roots, err := f.roots()
+			if err != nil {
+				return err
+			}
+			return verify(cs, roots, serverName)
+		}
+	}
+	return cfg
+}
+
+// verify does what crypto/tls does for a client with RootCAs set. The leaf
+// must be valid for serverName, or for the SNI name when serverName is empty.
+func verify(cs tls.ConnectionState, roots *x509.CertPool, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("tls: server sent no certificates")
+	}
+	if serverName == "" {
+		serverName = cs.ServerName
+	}
+	if serverName == "" {
+		return fmt.Errorf("tls: no server name to verify the certificate against")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return fmt.Errorf("tls: failed to verify certificate: %w", err)
+	}
+	return nil
+}
+
+// ServerName returns the host of addr, a host:port or a bare host, for
+// ClientConfigFor.
+func ServerName(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+// fileCache holds the files of every Config by their paths, so configs
+// built per connection share the parsed certificates.
+var fileCache sync.Map // filePaths -> *files
+
+type filePaths struct {
+	ca, cert, key string
+}
+
+func filesFor(c Config) *files {
+	key := filePaths{ca: c.CAFile, cert: c.CertFile, key: c.KeyFile}
+	f, _ := fileCache.LoadOrStore(key, &files{c: c})
+	return f.(*files)
+}
+
+// files caches the CA bundle and client certificate of a Config by the
+// modification time of their files.
+type files struct {
+	c Config
+
+	mu      sync.Mutex
+	caMod   time.Time
+	pool    *x509.CertPool
+	certMod time.Time
+	keyMod  time.Time
+	cert    *tls.Certificate
+}
+
+func modTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+func (f *files) roots() (*x509.CertPool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	mod, err := modTime(f.c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("caFile: %w", err)
+	}
+	if f.pool != nil && mod.Equal(f.caMod) {
+		return f.pool, nil
+	}
+	pem, err := os.ReadFile(f.c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("caFile: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("caFile %s: no certificates found", f.c.CAFile)
+	}
+	f.pool, f.caMod = pool, mod
+	return pool, nil
+}
+
+func (f *files) clientCert() (*tls.Certificate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	certMod, err := modTime(f.c.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("certFile: %w", err)
+	}
+	keyMod, err := modTime(f.c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("keyFile: %w", err)
+	}
+	if f.cert != nil && certMod.Equal(f.certMod) && keyMod.Equal(f.keyMod) {
+		return f.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(f.c.CertFile, f.c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+	f.cert, f.certMod, f.keyMod = &cert, certMod, keyMod
+	return f.cert, nil
+}
@@ -0,0 +1,190 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA writes a CA certificate to a temp file and returns its path, the CA
+// and its key.
+func testCA(t *testing.T) (string, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, ca, key
+}
+
+// serve starts a TLS server on 127.0.0.1 whose certificate, signed by ca,
+// covers the given DNS names and IP addresses. It returns the server address.
+func serve(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, dnsNames []string, ips []net.IP) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func handshake(addr string, cfg *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 2 * time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func TestClientConfigVerifiesIPAddress(t *testing.T) {
+	caFile, ca, caKey := testCA(t)
+	c := Config{Enabled: true, CAFile: caFile}
+	tests := []struct {
+		name    string
+		ips     []net.IP
+		dns     []string
+		cfg     func(addr string) *tls.Config
+		wantErr bool
+	}{
+		{
+			name: "IP SAN matches",
+			ips:  []net.IP{net.ParseIP("127.0.0.1")},
+			cfg:  func(addr string) *tls.Config { return c.ClientConfigFor(ServerName(addr)) },
+		},
+		{
+			name:    "wrong IP SAN",
+			ips:     []net.IP{net.ParseIP("127.0.0.2")},
+			cfg:     func(addr string) *tls.Config { return c.ClientConfigFor(ServerName(addr)) },
+			wantErr: true,
+		},
+		{
+			name:    "DNS name only for an IP target",
+			dns:     []string{"db.example.com"},
+			cfg:     func(addr string) *tls.Config { return c.ClientConfigFor(ServerName(addr)) },
+			wantErr: true,
+		},
+		{
+			name:    "no server name to check against",
+			ips:     []net.IP{net.ParseIP("127.0.0.2")},
+			cfg:     func(string) *tls.Config { return c.ClientConfig() },
+			wantErr: true,
+		},
+		{
+			name: "configured serverName wins",
+			dns:  []string{"db.example.com"},
+			cfg: func(addr string) *tls.Config {
+				c := c
+				c.ServerName = "db.example.com"
+				return c.ClientConfigFor(ServerName(addr))
+			},
+		},
+		{
+			name: "SNI name",
+			dns:  []string{"db.example.com"},
+			cfg: func(string) *tls.Config {
+				cfg := c.ClientConfig()
+				cfg.ServerName = "db.example.com"
+				return cfg
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serve(t, ca, caKey, tt.dns, tt.ips)
+			err := handshake(addr, tt.cfg(addr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handshake error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientConfigRejectsOtherCA(t *testing.T) {
+	caFile, _, _ := testCA(t)
+	_, otherCA, otherKey := testCA(t)
+	addr := serve(t, otherCA, otherKey, nil, []net.IP{net.ParseIP("127.0.0.1")})
+	c := Config{Enabled: true, CAFile: caFile}
+	if err := handshake(addr, c.ClientConfigFor(ServerName(addr))); err == nil {
+		t.Error("handshake succeeded with a certificate from another CA")
+	}
+}
+
+func TestServerName(t *testing.T) {
+	for addr, want := range map[string]string{
+		"10.0.0.1:6379":      "10.0.0.1",
+		"redis.example:6380": "redis.example",
+		"[2001:db8::1]:443":  "2001:db8::1",
+		"db.example":         "db.example",
+		"[2001:db8::1]":      "2001:db8::1",
+		"2001:db8::1":        "2001:db8::1",
+		"":                   "",
+	} {
+		if got := ServerName(addr); got != want {
+			t.Errorf("ServerName(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
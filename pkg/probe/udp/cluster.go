@@ -0,0 +1,66 @@
+package udp
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+type UDPCluster struct {
+	probe.ClusterBase `yaml:",inline"`
+	Addresses         []string             `yaml:"addresses"`
+	Timeout           probe.DurationString `yaml:"timeout"`
+	// Payload is sent as is; PayloadHex is hex, e.g. "de ad be ef".
+	Payload    string `yaml:"payload"`
+	PayloadHex string `yaml:"payloadHex"`
+	// Expect is a regex and ExpectHex hex bytes the response must contain.
+	Expect    string `yaml:"expect"`
+	ExpectHex string `yaml:"expectHex"`
+}
+
+func init() {
+	probe.Register("udp", newProbers)
+}
+
+func (c UDPCluster) Validate() error {
+	if len(c.Addresses) == 0 {
+		return fmt.Errorf("at least one address is required")
+	}
+	if c.Payload != "" && c.PayloadHex != "" {
+		return fmt.Errorf("payload and payloadHex are mutually exclusive")
+	}
+	if _, err := decodeHex(c.PayloadHex); err != nil {
+		return fmt.Errorf("payloadHex: %w", err)
+	}
+	if _, err := decodeHex(c.ExpectHex); err != nil {
+		return fmt.Errorf("expectHex: %w", err)
+	}
+	if _, err := regexp.Compile(c.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	return nil
+}
+
+func newProbers(cluster UDPCluster) ([]probe.Prober, error) {
+	payload := []byte(cluster.Payload)
+	if cluster.PayloadHex != "" {
+		var err error
+		if payload, err = decodeHex(cluster.PayloadHex); err != nil {
+			return nil, err
+		}
+	}
+	expectBytes, err := decodeHex(cluster.ExpectHex)
+	if err != nil {
+		return nil, err
+	}
+	p := NewUDPProbe(cluster.Addresses,
+		WithTimeout(cluster.Timeout.ToDuration(2*time.Second)),
+		WithRegion(cluster.Region),
+		WithPayload(payload),
+		WithExpect(cluster.Expect),
+		WithExpectBytes(expectBytes),
+	)
+	return []probe.Prober{p}, nil
+}
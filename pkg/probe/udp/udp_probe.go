@@ -0,0 +1,184 @@
+// Package udp probes UDP services by sending a payload and optionally
+// matching the response.
+package udp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+// maxDatagram is the largest response read.
+const maxDatagram = 64 << 10
+
+var (
+	addressUp = probe.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prober_udp_address_up",
+		Help: "Whether the last probe of each UDP address succeeded",
+	}, "address")
+	rtt = probe.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prober_udp_rtt_seconds",
+		Help:    "Time from sending the payload to a matching response, per UDP address",
+		Buckets: prometheus.DefBuckets,
+	}, "address")
+)
+
+type UDPProbe struct {
+	Addresses []string
+	Timeout   time.Duration
+	Region    string
+	// Payload is sent in every probe; it may be empty.
+	Payload []byte
+	// With Expect or ExpectBytes set the probe waits for a response
+	// within Timeout; Expect is a regex and ExpectBytes must be contained
+	// in the response. Without either the probe only sends.
+	Expect      string
+	ExpectBytes []byte
+	expect      *regexp.Regexp
+}
+
+// Option configures a UDPProbe.
+type Option func(*UDPProbe)
+
+// WithTimeout sets the time to wait for a response per address (default 2s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *UDPProbe) { p.Timeout = timeout }
+}
+
+// WithRegion sets the region of the probed addresses.
+func WithRegion(region string) Option {
+	return func(p *UDPProbe) { p.Region = region }
+}
+
+// WithPayload sets the datagram sent to every address.
+func WithPayload(payload []byte) Option {
+	return func(p *UDPProbe) { p.Payload = payload }
+}
+
+// WithExpect waits for a response matching the regex.
+func WithExpect(expr string) Option {
+	return func(p *UDPProbe) { p.Expect = expr }
+}
+
+// WithExpectBytes waits for a response containing b.
+func WithExpectBytes(b []byte) Option {
+	return func(p *UDPProbe) { p.ExpectBytes = b }
+}
+
+// NewUDPProbe creates a UDPProbe that sends to every address.
+func NewUDPProbe(addresses []string, opts ...Option) *UDPProbe {
+	p := &UDPProbe{
+		Addresses: addresses,
+		Timeout:   2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// decodeHex decodes a hex payload; whitespace between bytes is allowed.
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+func (p *UDPProbe) waits() bool {
+	return p.Expect != "" || len(p.ExpectBytes) > 0
+}
+
+// Probe implements the Prober interface. The addresses are probed
+// concurrently and all of them must succeed.
+func (p *UDPProbe) Probe(ctx context.Context) error {
+	if p.expect == nil && p.Expect != "" {
+		re, err := regexp.Compile(p.Expect)
+		if err != nil {
+			return err
+		}
+		p.expect = re
+	}
+	errs := make([]error, len(p.Addresses))
+	var wg sync.WaitGroup
+	for i, addr := range p.Addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := p.probeAddress(ctx, addr)
+			errs[i] = err
+			up := 0.0
+			if err == nil {
+				up = 1
+				if p.waits() {
+					rtt.Observe(ctx, d.Seconds(), addr)
+				}
+			}
+			addressUp.Set(ctx, up, addr)
+		}()
+	}
+	wg.Wait()
+	var failed []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("udp probe errors: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// probeAddress sends the payload to addr and waits for a matching response,
+// returning the round-trip time.
+func (p *UDPProbe) probeAddress(ctx context.Context, addr string) (time.Duration, error) {
+	dialer := &net.Dialer{Timeout: p.Timeout}
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, fmt.Errorf("%s: dial error: %v", addr, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(p.Timeout)); err != nil {
+		return 0, fmt.Errorf("%s: set deadline error: %v", addr, err)
+	}
+	start := time.Now()
+	if _, err := conn.Write(p.Payload); err != nil {
+		return 0, fmt.Errorf("%s: write error: %v", addr, err)
+	}
+	if !p.waits() {
+		return 0, nil
+	}
+	// Stop waiting when the probe is cancelled.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	buf := make([]byte, maxDatagram)
+	// Datagrams that don't match, e.g. late answers to an earlier probe,
+	// are skipped until the deadline.
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, fmt.Errorf("%s: read error: %v", addr, err)
+		}
+		if p.matches(buf[:n]) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+func (p *UDPProbe) matches(resp []byte) bool {
+	if p.expect != nil && !p.expect.Match(resp) {
+		return false
+	}
+	return bytes.Contains(resp, p.ExpectBytes)
+}
+
+func (p *UDPProbe) MetadataString() string {
+	return fmt.Sprintf("addresses: %v , region: %s , payload: %d bytes , expect: %v", p.Addresses, p.Region, len(p.Payload), p.waits())
+}
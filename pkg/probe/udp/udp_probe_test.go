@@ -0,0 +1,193 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourorg/prober/pkg/probe"
+)
+
+// startServer serves UDP on 127.0.0.1, answering every datagram with the
+// datagrams returned by reply. Received datagrams are sent on the returned
+// channel.
+func startServer(t *testing.T, reply func(req []byte) [][]byte) (string, <-chan []byte) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	received := make(chan []byte, 10)
+	go func() {
+		buf := make([]byte, maxDatagram)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte{}, buf[:n]...)
+			select {
+			case received <- req:
+			default:
+			}
+			for _, resp := range reply(req) {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String(), received
+}
+
+func echo(req []byte) [][]byte { return [][]byte{req} }
+
+func newClusterProbe(t *testing.T, cluster UDPCluster) *UDPProbe {
+	t.Helper()
+	if err := cluster.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	probers, err := newProbers(cluster)
+	if err != nil {
+		t.Fatalf("newProbers: %v", err)
+	}
+	return probers[0].(*UDPProbe)
+}
+
+func TestProbeExpect(t *testing.T) {
+	addr, _ := startServer(t, echo)
+	tests := []struct {
+		name    string
+		cluster UDPCluster
+		wantErr bool
+	}{
+		{
+			name:    "regex matches",
+			cluster: UDPCluster{Payload: "ping 42", Expect: `^ping \d+$`},
+		},
+		{
+			name:    "hex matches",
+			cluster: UDPCluster{PayloadHex: "de ad be ef", ExpectHex: "adbe"},
+		},
+		{
+			name:    "regex and hex both match",
+			cluster: UDPCluster{Payload: "hello", Expect: "^hel", ExpectHex: "6c6c6f"},
+		},
+		{
+			name:    "regex does not match",
+			cluster: UDPCluster{Payload: "ping", Expect: "^pong$"},
+			wantErr: true,
+		},
+		{
+			name:    "hex does not match",
+			cluster: UDPCluster{PayloadHex: "0102", ExpectHex: "03"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cluster.Addresses = []string{addr}
+			tt.cluster.Timeout = "200ms"
+			err := newClusterProbe(t, tt.cluster).Probe(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Probe error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeSkipsNonMatchingDatagrams(t *testing.T) {
+	addr, _ := startServer(t, func(req []byte) [][]byte {
+		return [][]byte{[]byte("late answer"), []byte("noise"), []byte("OK " + string(req))}
+	})
+	p := NewUDPProbe([]string{addr}, WithTimeout(time.Second), WithPayload([]byte("id-1")), WithExpect("^OK id-1$"))
+	if err := p.Probe(context.Background()); err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	addr, received := startServer(t, func([]byte) [][]byte { return nil })
+	p := NewUDPProbe([]string{addr}, WithTimeout(100*time.Millisecond), WithPayload([]byte("ping")), WithExpect("pong"))
+	start := time.Now()
+	err := p.Probe(context.Background())
+	if err == nil {
+		t.Fatal("Probe succeeded without a response")
+	}
+	if !strings.Contains(err.Error(), addr) {
+		t.Errorf("error %q does not name the address %s", err, addr)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Probe took %v, want about the 100ms timeout", elapsed)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Error("server received no payload")
+	}
+}
+
+func TestProbeCancelled(t *testing.T) {
+	addr, _ := startServer(t, func([]byte) [][]byte { return nil })
+	p := NewUDPProbe([]string{addr}, WithTimeout(10*time.Second), WithExpect("pong"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Probe(ctx); err == nil {
+		t.Fatal("Probe succeeded without a response")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Probe took %v after the context was cancelled", elapsed)
+	}
+}
+
+func TestProbeSendOnly(t *testing.T) {
+	addr, received := startServer(t, func([]byte) [][]byte { return nil })
+	p := NewUDPProbe([]string{addr}, WithTimeout(time.Second), WithPayload([]byte("metric:1|c")))
+	if err := p.Probe(context.Background()); err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	select {
+	case got := <-received:
+		if string(got) != "metric:1|c" {
+			t.Errorf("server received %q, want %q", got, "metric:1|c")
+		}
+	case <-time.After(time.Second):
+		t.Error("server received no payload")
+	}
+}
+
+func TestProbeAllAddressesMustSucceed(t *testing.T) {
+	ok, _ := startServer(t, echo)
+	silent, _ := startServer(t, func([]byte) [][]byte { return nil })
+	p := NewUDPProbe([]string{ok, silent}, WithTimeout(100*time.Millisecond), WithPayload([]byte("ping")), WithExpect("ping"))
+	ctx := probe.ContextWithTarget(context.Background(), probe.Target{Kind: "udp", Operation: "probe", Name: "test"})
+	err := p.Probe(ctx)
+	if err == nil {
+		t.Fatal("Probe succeeded with a silent address")
+	}
+	if strings.Contains(err.Error(), ok+":") {
+		t.Errorf("error %q blames the responding address %s", err, ok)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster UDPCluster
+	}{
+		{"no addresses", UDPCluster{}},
+		{"payload and payloadHex", UDPCluster{Addresses: []string{"x:1"}, Payload: "a", PayloadHex: "61"}},
+		{"bad payloadHex", UDPCluster{Addresses: []string{"x:1"}, PayloadHex: "zz"}},
+		{"bad expectHex", UDPCluster{Addresses: []string{"x:1"}, ExpectHex: "abc"}},
+		{"bad expect", UDPCluster{Addresses: []string{"x:1"}, Expect: "("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cluster.Validate(); err == nil {
+				t.Error("Validate accepted an invalid cluster")
+			}
+		})
+	}
+}